@@ -1,14 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"hex/config"
 	"hex/internal/adapters/auth"
 	"hex/internal/adapters/cors"
+	eventadapters "hex/internal/adapters/events"
 	"hex/internal/adapters/http/handlers"
+	"hex/internal/adapters/http/middleware"
 	"hex/internal/adapters/persistence"
+	"hex/internal/adapters/persistence/memory"
 	"hex/internal/adapters/seeder"
+	appauth "hex/internal/application/auth"
+	"hex/internal/application/events"
+	"hex/internal/application/repositories"
 	"hex/internal/application/services"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,9 +33,79 @@ func main() {
 	// Initialize the configuration
 	cfg := config.NewConfig()
 
+	// Initialize authentication service
+	var authService appauth.AuthService
+	if cfg.AuthProvider == config.AuthProviderJWT {
+		var err error
+		authService, err = auth.NewJWTAuthService(auth.JWTOptions{
+			Algorithm:     cfg.JWTAlgorithm,
+			Secret:        []byte(cfg.JWTSecret),
+			PublicKeyFile: cfg.JWTPublicKeyFile,
+			JWKSFile:      cfg.JWTJWKSFile,
+			UserIDClaim:   cfg.JWTUserIDClaim,
+			RoleClaim:     cfg.JWTRoleClaim,
+		})
+		if err != nil {
+			log.Fatalf("Error configuring JWT authentication: %v", err)
+		}
+	} else {
+		authService = auth.NewRailsAuthService(cfg.RailsAPIURL, cfg.AuthHTTPTimeout)
+		if cfg.AuthCacheTTL > 0 {
+			authService = auth.NewCachingAuthService(authService, auth.CacheOptions{
+				TTL:         cfg.AuthCacheTTL,
+				NegativeTTL: cfg.AuthCacheNegativeTTL,
+				MaxEntries:  cfg.AuthCacheSize,
+			})
+		}
+	}
+
+	// Initialize repositories
+	var (
+		bookRepo      repositories.BookRepository
+		copyRepo      repositories.CopyRepository
+		borrowingRepo repositories.BorrowingRepository
+		fineRepo      repositories.FineRepository
+		holdRepo      repositories.HoldRepository
+		auditRepo     repositories.AuditRepository
+		outboxRepo    repositories.OutboxRepository
+		webhookRepo   repositories.WebhookRepository
+		deliveryRepo  repositories.WebhookDeliveryRepository
+		transactor    repositories.Transactor
+	)
+	if cfg.Storage == config.StorageMemory {
+		store := memory.NewStore()
+		bookRepo = memory.NewBookRepository(store)
+		copyRepo = memory.NewCopyRepository(store)
+		borrowingRepo = memory.NewBorrowingRepository(store)
+		fineRepo = memory.NewFineRepository(store)
+		holdRepo = memory.NewHoldRepository(store)
+		auditRepo = memory.NewAuditRepository(store)
+		outboxRepo = memory.NewOutboxRepository(store)
+		webhookRepo = memory.NewWebhookRepository(store)
+		deliveryRepo = memory.NewWebhookDeliveryRepository(store)
+		transactor = memory.NewTransactor(store)
+	} else {
+		bookRepo = persistence.NewBookRepository(cfg.DB)
+		copyRepo = persistence.NewCopyRepository(cfg.DB)
+		borrowingRepo = persistence.NewBorrowingRepository(cfg.DB)
+		fineRepo = persistence.NewFineRepository(cfg.DB)
+		holdRepo = persistence.NewHoldRepository(cfg.DB)
+		auditRepo = persistence.NewAuditRepository(cfg.DB)
+		outboxRepo = persistence.NewOutboxRepository(cfg.DB)
+		webhookRepo = persistence.NewWebhookRepository(cfg.DB)
+		deliveryRepo = persistence.NewWebhookDeliveryRepository(cfg.DB)
+		transactor = persistence.NewTransactor(cfg.DB)
+	}
+
 	// Run seeding if the environment variable is set to true
 	if cfg.SeedDatabase {
-		if err := seeder.Seed(cfg.DB); err != nil {
+		var err error
+		if cfg.Storage == config.StorageMemory {
+			err = seeder.SeedRepository(bookRepo, copyRepo)
+		} else {
+			err = seeder.Seed(cfg.DB)
+		}
+		if err != nil {
 			log.Fatalf("Error seeding database: %v", err)
 		}
 		log.Println("Database seeding completed.")
@@ -27,20 +113,43 @@ func main() {
 		log.Println("Database seeding is disabled.")
 	}
 
-	// Initialize authentication service
-	authService := auth.NewRailsAuthService(cfg.RailsAPIURL)
-
-	// Initialize repositories
-	bookRepo := persistence.NewBookRepository(cfg.DB)
-	borrowingRepo := persistence.NewBorrowingRepository(cfg.DB)
-
 	// Initialize services
-	bookService := services.NewBookService(*bookRepo, cfg.Logger)
-	borrowingService := services.NewBorrowingService(*bookRepo, *borrowingRepo, cfg.Logger)
+	bookService := services.NewBookService(bookRepo, transactor, cfg.Logger)
+	borrowingPolicy := services.BorrowingPolicy{
+		MaxLoans:     cfg.MaxLoans,
+		RoleMaxLoans: map[appauth.Role]int{},
+		BlockOverdue: cfg.BlockOverdueBorrowers,
+	}
+	for role, limit := range cfg.MaxLoansByRole {
+		borrowingPolicy.RoleMaxLoans[appauth.Role(strings.ToLower(role))] = limit
+	}
+	loanPolicy := services.LoanPolicy{
+		DefaultDays: cfg.LoanPeriodDays,
+		GenreDays:   cfg.LoanPeriodsByGenre,
+		MaxRenewals: uint(cfg.MaxRenewals),
+	}
+	finePolicy := services.FinePolicy{
+		DailyRateCents:      cfg.FineDailyRateCents,
+		CapCents:            cfg.FineCapCents,
+		GraceDays:           cfg.FineGraceDays,
+		BlockThresholdCents: cfg.FineBlockThresholdCents,
+	}
+	holdPolicy := services.HoldPolicy{PickupWindow: cfg.HoldPickupWindow}
+	borrowingService := services.NewBorrowingService(borrowingRepo, transactor, borrowingPolicy, loanPolicy, finePolicy, holdPolicy, cfg.Logger)
+	fineService := services.NewFineService(fineRepo, transactor, cfg.Logger)
+	holdService := services.NewHoldService(holdRepo, transactor, holdPolicy, cfg.Logger)
+	copyService := services.NewCopyService(copyRepo, transactor, holdPolicy, cfg.Logger)
+	auditService := services.NewAuditService(auditRepo, cfg.Logger)
+	webhookService := services.NewWebhookService(webhookRepo, deliveryRepo, transactor, cfg.Logger)
 
 	// Initialize handlers
-	bookHandler := handlers.NewBookHandler(bookService, authService)
-	borrowingHandler := handlers.NewBorrowingHandler(borrowingService, authService)
+	bookHandler := handlers.NewBookHandler(bookService)
+	copyHandler := handlers.NewCopyHandler(copyService)
+	borrowingHandler := handlers.NewBorrowingHandler(borrowingService)
+	fineHandler := handlers.NewFineHandler(fineService)
+	holdHandler := handlers.NewHoldHandler(holdService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
 
 	// Setup Gin router
 	r := gin.Default()
@@ -48,17 +157,163 @@ func main() {
 	// Configure CORS
 	cors.ConfigureCORS(r)
 
+	// Tag every request so its log lines and audit entries can be tied together
+	r.Use(middleware.RequestID())
+
+	// Every route requires an authenticated user; role guards narrow it down
+	api := r.Group("/", middleware.Authenticate(authService, cfg.Logger))
+	staffOnly := middleware.RequireRole(appauth.RoleAdmin, appauth.RoleLibrarian)
+	membersOnly := middleware.RequireRole(appauth.RoleMember)
+	adminOnly := middleware.RequireRole(appauth.RoleAdmin)
+
 	// Define routes
-	r.POST("/books", bookHandler.CreateBook)
-	r.GET("/books", bookHandler.ViewAllBooks)
-	r.PUT("/books/:id", bookHandler.UpdateBook)
-	r.DELETE("/books/:id", bookHandler.DeleteBook)
-
-	r.POST("/borrow", borrowingHandler.BorrowBook)
-	r.POST("/return", borrowingHandler.ReturnBook)
-	r.GET("/my-borrowings", borrowingHandler.GetMyBorrowings)
-	r.GET("/borrowing-records", borrowingHandler.GetAllBorrowingRecords)
-
-	// Run the server
-	r.Run(":" + cfg.Port)
+	api.POST("/books", staffOnly, bookHandler.CreateBook)
+	api.POST("/books/import", staffOnly, bookHandler.ImportBooks)
+	api.GET("/books", bookHandler.ViewAllBooks)
+	api.GET("/books/search", bookHandler.SearchBooks)
+	api.GET("/books/export", staffOnly, bookHandler.ExportBooks)
+	api.GET("/books/:id", bookHandler.GetBook)
+	api.PUT("/books/:id", staffOnly, bookHandler.UpdateBook)
+	api.PATCH("/books/:id", staffOnly, bookHandler.PatchBook)
+	api.DELETE("/books/:id", staffOnly, bookHandler.DeleteBook)
+	api.POST("/books/:id/restore", staffOnly, bookHandler.RestoreBook)
+	api.POST("/books/:id/purge", adminOnly, bookHandler.PurgeBook)
+
+	api.GET("/books/:id/copies", staffOnly, copyHandler.ListCopies)
+	api.POST("/books/:id/copies", staffOnly, copyHandler.AddCopy)
+	api.PATCH("/copies/:id", staffOnly, copyHandler.UpdateCopy)
+
+	api.POST("/borrow", membersOnly, borrowingHandler.BorrowBook)
+	api.POST("/return", membersOnly, borrowingHandler.ReturnBook)
+	api.POST("/borrowings/:id/renew", membersOnly, borrowingHandler.RenewBorrowing)
+	api.GET("/my-borrowings", membersOnly, borrowingHandler.GetMyBorrowings)
+	api.GET("/borrowing-records", staffOnly, borrowingHandler.GetAllBorrowingRecords)
+	api.GET("/borrowing-records/export", staffOnly, borrowingHandler.ExportBorrowingRecords)
+
+	api.POST("/books/:id/holds", membersOnly, holdHandler.PlaceHold)
+	api.GET("/books/:id/holds", staffOnly, holdHandler.GetBookHolds)
+	api.GET("/my-holds", membersOnly, holdHandler.GetMyHolds)
+	api.DELETE("/holds/:id", membersOnly, holdHandler.CancelHold)
+
+	api.GET("/my-fines", membersOnly, fineHandler.GetMyFines)
+	api.GET("/fines", staffOnly, fineHandler.GetAllFines)
+	api.POST("/fines/:id/payments", staffOnly, fineHandler.RecordPayment)
+	api.POST("/fines/:id/waive", staffOnly, fineHandler.WaiveFine)
+
+	api.GET("/audit", adminOnly, auditHandler.ListEntries)
+
+	api.POST("/webhooks", adminOnly, webhookHandler.CreateWebhook)
+	api.GET("/webhooks", adminOnly, webhookHandler.ListWebhooks)
+	api.GET("/webhooks/:id", adminOnly, webhookHandler.GetWebhook)
+	api.PATCH("/webhooks/:id", adminOnly, webhookHandler.UpdateWebhook)
+	api.DELETE("/webhooks/:id", adminOnly, webhookHandler.DeleteWebhook)
+	api.GET("/webhooks/:id/deliveries", adminOnly, webhookHandler.ListDeliveries)
+	api.POST("/webhooks/:id/deliveries/:delivery_id/retry", adminOnly, webhookHandler.RetryDelivery)
+
+	// Run the server until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error running server: %v", err)
+		}
+	}()
+
+	// Background work runs until the server has shut down, so that events
+	// from the last requests are still relayed
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	// Raise LoanOverdue as loans pass their due dates
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(cfg.OverdueScanInterval)
+		defer ticker.Stop()
+		for {
+			borrowingService.NotifyOverdueLoans()
+			select {
+			case <-workers.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Pass copies on from holds whose pickup window has lapsed
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(cfg.HoldExpiryInterval)
+		defer ticker.Stop()
+		for {
+			holdService.ExpireHolds()
+			select {
+			case <-workers.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Relay domain events from the outbox
+	var publisher events.Publisher
+	switch cfg.EventPublisher {
+	case config.EventPublisherStdout:
+		publisher = eventadapters.NewStdoutPublisher(os.Stdout)
+	case config.EventPublisherWebhook:
+		publisher = eventadapters.NewWebhookPublisher(cfg.EventWebhookURL, cfg.EventWebhookTimeout)
+	}
+	if publisher != nil {
+		dispatcher := services.NewOutboxDispatcher(outboxRepo, transactor, publisher, services.OutboxPolicy{
+			PollInterval: cfg.OutboxPollInterval,
+			BatchSize:    cfg.OutboxBatchSize,
+			Lease:        cfg.OutboxLease,
+			RetryBase:    cfg.OutboxRetryBase,
+			RetryMax:     cfg.OutboxRetryMax,
+		}, cfg.Logger)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.Run(workers)
+		}()
+		log.Printf("Publishing events to %s.", cfg.EventPublisher)
+	} else {
+		log.Println("Event publishing is disabled; events stay in the outbox.")
+	}
+
+	// Deliver events to webhook subscriptions
+	webhookDispatcher := services.NewWebhookDispatcher(deliveryRepo, transactor, eventadapters.NewHTTPWebhookSender(cfg.WebhookTimeout), services.WebhookPolicy{
+		PollInterval: cfg.WebhookPollInterval,
+		BatchSize:    cfg.WebhookBatchSize,
+		Lease:        cfg.WebhookLease,
+		RetryBase:    cfg.WebhookRetryBase,
+		RetryMax:     cfg.WebhookRetryMax,
+		MaxAttempts:  uint(cfg.WebhookMaxAttempts),
+	}, cfg.Logger)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		webhookDispatcher.Run(workers)
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	stopWorkers()
+	wg.Wait()
+
+	// Flush any log entries still queued
+	if closer, ok := cfg.Logger.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Error closing logger: %v", err)
+		}
+	}
 }
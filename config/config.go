@@ -1,11 +1,14 @@
 package config
 
 import (
-	"hex/internal/adapters/logging"
-	"hex/pkg/models"
+	"errors"
+	logadapters "hex/internal/adapters/logging"
+	"hex/internal/adapters/persistence"
+	"hex/internal/application/logging"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,12 +16,108 @@ import (
 	"gorm.io/gorm"
 )
 
+// Supported values for the STORAGE environment variable.
+const (
+	StorageMySQL  = "mysql"
+	StorageMemory = "memory"
+)
+
+// Supported values for the AUTH_PROVIDER environment variable.
+const (
+	AuthProviderRails = "rails"
+	AuthProviderJWT   = "jwt"
+)
+
+// Supported values for the LOGGER environment variable.
+const (
+	LoggerMongoDB = "mongodb"
+	LoggerStdout  = "stdout"
+	LoggerNoop    = "noop"
+)
+
+// Supported values for the EVENT_PUBLISHER environment variable.
+const (
+	EventPublisherNone    = "none"
+	EventPublisherStdout  = "stdout"
+	EventPublisherWebhook = "webhook"
+)
+
 type Config struct {
 	DB           *gorm.DB
+	Storage      string
 	Port         string
+	AuthProvider string
 	RailsAPIURL  string
-	Logger       *logging.MongoDBLogger
+	Logger       logging.Logger
 	SeedDatabase bool
+
+	// AuthHTTPTimeout bounds each call to the Rails token verification API.
+	AuthHTTPTimeout time.Duration
+	// AuthCacheTTL and AuthCacheNegativeTTL control how long valid and
+	// rejected tokens are remembered; a zero AuthCacheTTL disables caching.
+	AuthCacheTTL         time.Duration
+	AuthCacheNegativeTTL time.Duration
+	AuthCacheSize        int
+
+	// LoanPeriodDays is the default loan length; LoanPeriodsByGenre overrides
+	// it per genre and is read from "Genre=days" pairs separated by commas.
+	LoanPeriodDays     int
+	LoanPeriodsByGenre map[string]int
+	MaxRenewals        int
+
+	// Late-return fines, in cents. FineBlockThresholdCents is the unpaid
+	// balance above which members may not borrow; negative disables it.
+	FineDailyRateCents      int64
+	FineCapCents            int64
+	FineGraceDays           int
+	FineBlockThresholdCents int64
+
+	// MaxLoans caps concurrent loans per member, zero meaning no limit.
+	// MaxLoansByRole overrides it per role, read from "role=count" pairs.
+	// BlockOverdueBorrowers stops members with overdue items from borrowing.
+	MaxLoans              int
+	MaxLoansByRole        map[string]int
+	BlockOverdueBorrowers bool
+
+	// HoldPickupWindow is how long a returned copy stays set aside for the
+	// member at the front of the hold queue.
+	HoldPickupWindow time.Duration
+	// HoldExpiryInterval is how often ready holds are checked for having
+	// passed their pickup window.
+	HoldExpiryInterval time.Duration
+
+	// EventPublisher is where domain events are relayed from the outbox.
+	// With "none" no dispatcher runs and events wait in the outbox.
+	// EventWebhookURL receives them when it is "webhook".
+	EventPublisher      string
+	EventWebhookURL     string
+	EventWebhookTimeout time.Duration
+	// Outbox dispatcher tuning; see services.OutboxPolicy.
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxLease        time.Duration
+	OutboxRetryBase    time.Duration
+	OutboxRetryMax     time.Duration
+	// OverdueScanInterval is how often loans are checked for having become
+	// overdue, raising LoanOverdue.
+	OverdueScanInterval time.Duration
+	// Webhook dispatcher tuning; see services.WebhookPolicy. WebhookTimeout
+	// bounds each delivery attempt.
+	WebhookTimeout      time.Duration
+	WebhookPollInterval time.Duration
+	WebhookBatchSize    int
+	WebhookLease        time.Duration
+	WebhookRetryBase    time.Duration
+	WebhookRetryMax     time.Duration
+	WebhookMaxAttempts  int
+
+	// JWT settings, used when AuthProvider is "jwt".
+	JWTAlgorithm     string
+	JWTSecret        string
+	JWTPublicKeyFile string
+	JWTJWKSFile      string
+	JWTUserIDClaim   string
+	JWTRoleClaim     string
 }
 
 func NewConfig() *Config {
@@ -27,7 +126,114 @@ func NewConfig() *Config {
 		log.Printf("Error loading .env file, using environment variables")
 	}
 
+	storage := os.Getenv("STORAGE")
+	if storage == "" {
+		storage = StorageMySQL
+	}
+
+	var db *gorm.DB
+	switch storage {
+	case StorageMySQL:
+		db = connectMySQL()
+	case StorageMemory:
+		log.Println("Using in-memory storage; data will be lost on restart.")
+	default:
+		log.Fatalf("Unknown STORAGE %q, expected %q or %q", storage, StorageMySQL, StorageMemory)
+	}
+
+	logger := newLogger()
+
+	authProvider := os.Getenv("AUTH_PROVIDER")
+	if authProvider == "" {
+		authProvider = AuthProviderRails
+	}
+	if authProvider != AuthProviderRails && authProvider != AuthProviderJWT {
+		log.Fatalf("Unknown AUTH_PROVIDER %q, expected %q or %q", authProvider, AuthProviderRails, AuthProviderJWT)
+	}
+
+	eventPublisher := os.Getenv("EVENT_PUBLISHER")
+	if eventPublisher == "" {
+		eventPublisher = EventPublisherNone
+	}
+	switch eventPublisher {
+	case EventPublisherNone, EventPublisherStdout:
+	case EventPublisherWebhook:
+		if os.Getenv("EVENT_WEBHOOK_URL") == "" {
+			log.Fatalf("EVENT_PUBLISHER %q requires EVENT_WEBHOOK_URL", eventPublisher)
+		}
+	default:
+		log.Fatalf("Unknown EVENT_PUBLISHER %q, expected %q, %q or %q", eventPublisher, EventPublisherNone, EventPublisherStdout, EventPublisherWebhook)
+	}
+
+	webhookMaxAttempts := envInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	if webhookMaxAttempts < 1 {
+		log.Fatalf("WEBHOOK_MAX_ATTEMPTS must be at least 1, got %d", webhookMaxAttempts)
+	}
+
+	seedDatabase, err := strconv.ParseBool(os.Getenv("SEED_DATABASE"))
+	if err != nil {
+		seedDatabase = false
+	}
+
+	return &Config{
+		DB:           db,
+		Storage:      storage,
+		Port:         os.Getenv("PORT"),
+		AuthProvider: authProvider,
+		RailsAPIURL:  os.Getenv("RAILS_API_URL"),
+		Logger:       logger,
+		SeedDatabase: seedDatabase,
+
+		AuthHTTPTimeout:      envDuration("AUTH_HTTP_TIMEOUT", 5*time.Second),
+		AuthCacheTTL:         envDuration("AUTH_CACHE_TTL", time.Minute),
+		AuthCacheNegativeTTL: envDuration("AUTH_CACHE_NEGATIVE_TTL", 10*time.Second),
+		AuthCacheSize:        envInt("AUTH_CACHE_SIZE", 10000),
+
+		LoanPeriodDays:     envInt("LOAN_PERIOD_DAYS", 14),
+		LoanPeriodsByGenre: envIntMap("LOAN_PERIODS_BY_GENRE"),
+		MaxRenewals:        envInt("MAX_RENEWALS", 2),
+
+		FineDailyRateCents:      int64(envInt("FINE_DAILY_RATE_CENTS", 25)),
+		FineCapCents:            int64(envInt("FINE_CAP_CENTS", 1000)),
+		FineGraceDays:           envInt("FINE_GRACE_DAYS", 0),
+		FineBlockThresholdCents: int64(envInt("FINE_BLOCK_THRESHOLD_CENTS", 500)),
+
+		MaxLoans:              envInt("MAX_LOANS", 5),
+		MaxLoansByRole:        envIntMap("MAX_LOANS_BY_ROLE"),
+		BlockOverdueBorrowers: envBool("BLOCK_OVERDUE_BORROWERS", true),
+
+		HoldPickupWindow:   envDuration("HOLD_PICKUP_WINDOW", 72*time.Hour),
+		HoldExpiryInterval: envDuration("HOLD_EXPIRY_INTERVAL", time.Minute),
+
+		EventPublisher:      eventPublisher,
+		EventWebhookURL:     os.Getenv("EVENT_WEBHOOK_URL"),
+		EventWebhookTimeout: envDuration("EVENT_WEBHOOK_TIMEOUT", 10*time.Second),
+		OutboxPollInterval:  envDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:     envInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxLease:         envDuration("OUTBOX_LEASE", time.Minute),
+		OutboxRetryBase:     envDuration("OUTBOX_RETRY_BASE", 5*time.Second),
+		OutboxRetryMax:      envDuration("OUTBOX_RETRY_MAX", time.Hour),
+		OverdueScanInterval: envDuration("OVERDUE_SCAN_INTERVAL", 15*time.Minute),
+		WebhookTimeout:      envDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookPollInterval: envDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		WebhookBatchSize:    envInt("WEBHOOK_BATCH_SIZE", 20),
+		WebhookLease:        envDuration("WEBHOOK_LEASE", 5*time.Minute),
+		WebhookRetryBase:    envDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookRetryMax:     envDuration("WEBHOOK_RETRY_MAX", 6*time.Hour),
+		WebhookMaxAttempts:  webhookMaxAttempts,
+
+		JWTAlgorithm:     os.Getenv("JWT_ALGORITHM"),
+		JWTSecret:        os.Getenv("JWT_SECRET"),
+		JWTPublicKeyFile: os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWTJWKSFile:      os.Getenv("JWT_JWKS_FILE"),
+		JWTUserIDClaim:   os.Getenv("JWT_USER_ID_CLAIM"),
+		JWTRoleClaim:     os.Getenv("JWT_ROLE_CLAIM"),
+	}
+}
+
+func connectMySQL() *gorm.DB {
 	var db *gorm.DB
+	var err error
 	maxRetries := 5
 	for i := 0; i < maxRetries; i++ {
 		dsn := os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASSWORD") + "@tcp(" + os.Getenv("DB_HOST") + ":3306)/" + os.Getenv("DB_NAME") + "?charset=utf8mb4&parseTime=True&loc=Local"
@@ -42,20 +248,96 @@ func NewConfig() *Config {
 		log.Fatalf("Error connecting to database after %d attempts: %v", maxRetries, err)
 	}
 
-	db.AutoMigrate(&models.Book{}, &models.BorrowingRecord{})
+	if err := persistence.Migrate(db); err != nil {
+		log.Fatalf("Error migrating database: %v", err)
+	}
+	return db
+}
 
-	logger := logging.NewMongoDBLogger(os.Getenv("MONGODB_URI"), os.Getenv("MONGODB_DB"), os.Getenv("MONGODB_COLLECTION"))
+// newLogger picks the logger named by LOGGER. When LOGGER is unset, MongoDB
+// is used if MONGODB_URI is configured and stdout otherwise. An unreachable
+// MongoDB falls back to stdout instead of stopping the API.
+func newLogger() logging.Logger {
+	kind := os.Getenv("LOGGER")
+	if kind == "" {
+		kind = LoggerStdout
+		if os.Getenv("MONGODB_URI") != "" {
+			kind = LoggerMongoDB
+		}
+	}
 
-	seedDatabase, err := strconv.ParseBool(os.Getenv("SEED_DATABASE"))
+	switch kind {
+	case LoggerMongoDB:
+		opts := logadapters.MongoDBOptions{
+			QueueSize:     envInt("MONGODB_LOG_QUEUE_SIZE", 0),
+			BatchSize:     envInt("MONGODB_LOG_BATCH_SIZE", 0),
+			FlushInterval: envDuration("MONGODB_LOG_FLUSH_INTERVAL", 0),
+			Overflow:      logadapters.OverflowPolicy(os.Getenv("MONGODB_LOG_OVERFLOW")),
+		}
+		logger, err := logadapters.NewMongoDBLogger(os.Getenv("MONGODB_URI"), os.Getenv("MONGODB_DB"), os.Getenv("MONGODB_COLLECTION"), opts)
+		if errors.Is(err, logadapters.ErrInvalidOverflow) {
+			log.Fatalf("Invalid MONGODB_LOG_OVERFLOW: %v", err)
+		}
+		if err != nil {
+			log.Printf("%v; logging to stdout instead", err)
+			return logadapters.NewSlogLogger(os.Stdout)
+		}
+		return logger
+	case LoggerStdout:
+		return logadapters.NewSlogLogger(os.Stdout)
+	case LoggerNoop:
+		return logadapters.NewNopLogger()
+	default:
+		log.Fatalf("Unknown LOGGER %q, expected %q, %q or %q", kind, LoggerMongoDB, LoggerStdout, LoggerNoop)
+		return nil
+	}
+}
+
+// envInt reads an integer environment variable, returning def when it is
+// unset or malformed.
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
 	if err != nil {
-		seedDatabase = false
+		return def
 	}
+	return value
+}
 
-	return &Config{
-		DB:           db,
-		Port:         os.Getenv("PORT"),
-		RailsAPIURL:  os.Getenv("RAILS_API_URL"),
-		Logger:       logger,
-		SeedDatabase: seedDatabase,
+// envBool reads a boolean environment variable such as "true" or "0",
+// returning def when it is unset or malformed.
+func envBool(name string, def bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a duration such as "500ms" or "2s", returning def when
+// it is unset or malformed.
+func envDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envIntMap reads comma-separated "key=value" pairs with integer values, such
+// as "Fiction=21,Reference=7". Malformed pairs are skipped with a warning.
+func envIntMap(name string) map[string]int {
+	values := map[string]int{}
+	for _, pair := range strings.Split(os.Getenv(name), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || err != nil {
+			log.Printf("Ignoring malformed %s entry %q", name, pair)
+			continue
+		}
+		values[strings.TrimSpace(key)] = n
 	}
+	return values
 }
@@ -3,18 +3,32 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"hex/internal/application/auth"
 )
 
 type railsAuthService struct {
 	railsBaseURL string
+	client       *http.Client
 }
 
-func NewRailsAuthService(railsBaseURL string) auth.AuthService {
-	return &railsAuthService{railsBaseURL: railsBaseURL}
+// NewRailsAuthService verifies tokens against the Rails API. All calls share
+// one HTTP client, and each call gives up after timeout.
+func NewRailsAuthService(railsBaseURL string, timeout time.Duration) auth.AuthService {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	transport.MaxIdleConnsPerHost = 32
+
+	return &railsAuthService{
+		railsBaseURL: railsBaseURL,
+		client:       &http.Client{Timeout: timeout, Transport: transport},
+	}
 }
 
 func (s *railsAuthService) Authenticate(token string) (string, string, error) {
@@ -25,21 +39,21 @@ func (s *railsAuthService) Authenticate(token string) (string, string, error) {
 
 	req.Header.Set("Authorization", token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return "", "", err
 	}
 	defer resp.Body.Close()
 
+	// A 401 refuses the token whatever its body says
 	if resp.StatusCode == http.StatusUnauthorized {
 		var errResp struct {
 			Error string `json:"error"`
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-			return "", "", err
+			errResp.Error = ""
 		}
-		return "", "", fmt.Errorf(errResp.Error)
+		return "", "", &rejectedTokenError{message: errResp.Error}
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -57,3 +71,16 @@ func (s *railsAuthService) Authenticate(token string) (string, string, error) {
 	userID := string(authResp.UserID)
 	return userID, strings.ToLower(authResp.Role), nil
 }
+
+// rejectedTokenError means the token itself was refused, as opposed to the
+// verification failing. Only these errors are worth caching.
+type rejectedTokenError struct {
+	message string
+}
+
+func (e *rejectedTokenError) Error() string {
+	if e.message == "" {
+		return "token rejected"
+	}
+	return e.message
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRailsAuthRejectsOn401(t *testing.T) {
+	tests := map[string]struct {
+		body    string
+		message string
+	}{
+		"json":    {`{"error":"token revoked"}`, "token revoked"},
+		"html":    {"<html><body>Unauthorized</body></html>", "token rejected"},
+		"no body": {"", "token rejected"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, _, err := NewRailsAuthService(server.URL, time.Second).Authenticate("Bearer revoked")
+			var rejected *rejectedTokenError
+			if !errors.As(err, &rejected) || err.Error() != tt.message {
+				t.Errorf("Authenticate: err = %#v, want a rejection saying %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestRailsAuthDoesNotRejectOnServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, _, err := NewRailsAuthService(server.URL, time.Second).Authenticate("Bearer fine")
+	var rejected *rejectedTokenError
+	if err == nil || errors.As(err, &rejected) {
+		t.Errorf("Authenticate: err = %v, want a failure that is not a rejection", err)
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"hex/internal/application/auth"
+)
+
+// CacheOptions bounds how long and how many verification results are kept.
+type CacheOptions struct {
+	// TTL is how long a successful verification is reused.
+	TTL time.Duration
+	// NegativeTTL is how long a rejected token stays rejected without asking
+	// the upstream service again. Zero disables negative caching.
+	NegativeTTL time.Duration
+	// MaxEntries caps the cache size; the least recently used entry is
+	// evicted first.
+	MaxEntries int
+}
+
+type cachingAuthService struct {
+	next auth.AuthService
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	key       [sha256.Size]byte
+	userID    string
+	role      string
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingAuthService wraps next with an in-memory LRU cache. Tokens are
+// stored only as SHA-256 hashes. Transport failures are never cached, so a
+// brief outage of the upstream service does not lock users out.
+func NewCachingAuthService(next auth.AuthService, opts CacheOptions) auth.AuthService {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	return &cachingAuthService{
+		next:    next,
+		opts:    opts,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+func (s *cachingAuthService) Authenticate(token string) (string, string, error) {
+	key := sha256.Sum256([]byte(token))
+
+	if entry, ok := s.lookup(key); ok {
+		return entry.userID, entry.role, entry.err
+	}
+
+	userID, role, err := s.next.Authenticate(token)
+
+	var rejected *rejectedTokenError
+	switch {
+	case err == nil:
+		s.store(cacheEntry{key: key, userID: userID, role: role}, s.opts.TTL)
+	case errors.As(err, &rejected):
+		s.store(cacheEntry{key: key, err: err}, s.opts.NegativeTTL)
+	}
+	return userID, role, err
+}
+
+func (s *cachingAuthService) lookup(key [sha256.Size]byte) (cacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !s.now().Before(entry.expiresAt) {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+		return cacheEntry{}, false
+	}
+	s.lru.MoveToFront(elem)
+	return *entry, true
+}
+
+func (s *cachingAuthService) store(entry cacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	entry.expiresAt = s.now().Add(ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[entry.key]; ok {
+		elem.Value = &entry
+		s.lru.MoveToFront(elem)
+		return
+	}
+
+	s.entries[entry.key] = s.lru.PushFront(&entry)
+	for s.lru.Len() > s.opts.MaxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
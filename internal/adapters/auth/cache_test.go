@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeAuthService answers from a fixed table of tokens and counts the calls
+// that reach it.
+type fakeAuthService struct {
+	calls map[string]int
+	// fail makes every call fail as if the upstream service were down.
+	fail bool
+}
+
+func (f *fakeAuthService) Authenticate(token string) (string, string, error) {
+	if f.calls == nil {
+		f.calls = map[string]int{}
+	}
+	f.calls[token]++
+	switch {
+	case f.fail:
+		return "", "", errors.New("connection refused")
+	case token == "revoked":
+		return "", "", &rejectedTokenError{message: "token revoked"}
+	default:
+		return "user-" + token, "member", nil
+	}
+}
+
+// newCache wraps a fake upstream in a cache whose clock the test moves.
+func newCache(opts CacheOptions) (*cachingAuthService, *fakeAuthService, *time.Time) {
+	upstream := &fakeAuthService{}
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCachingAuthService(upstream, opts).(*cachingAuthService)
+	cache.now = func() time.Time { return clock }
+	return cache, upstream, &clock
+}
+
+func TestCacheReusesVerificationUntilTTL(t *testing.T) {
+	cache, upstream, clock := newCache(CacheOptions{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		userID, role, err := cache.Authenticate("a")
+		if err != nil || userID != "user-a" || role != "member" {
+			t.Fatalf("Authenticate = %q, %q, %v; want user-a, member", userID, role, err)
+		}
+	}
+	if upstream.calls["a"] != 1 {
+		t.Fatalf("upstream called %d times within the TTL, want 1", upstream.calls["a"])
+	}
+
+	*clock = clock.Add(time.Minute)
+	if _, _, err := cache.Authenticate("a"); err != nil {
+		t.Fatalf("Authenticate after the TTL: %v", err)
+	}
+	if upstream.calls["a"] != 2 {
+		t.Errorf("upstream called %d times once the TTL passed, want 2", upstream.calls["a"])
+	}
+}
+
+func TestCacheRemembersRejections(t *testing.T) {
+	cache, upstream, clock := newCache(CacheOptions{TTL: time.Hour, NegativeTTL: 10 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		var rejected *rejectedTokenError
+		if _, _, err := cache.Authenticate("revoked"); !errors.As(err, &rejected) {
+			t.Fatalf("Authenticate: err = %v, want the rejection", err)
+		}
+	}
+	if upstream.calls["revoked"] != 1 {
+		t.Fatalf("upstream called %d times for a rejected token, want 1", upstream.calls["revoked"])
+	}
+
+	*clock = clock.Add(10 * time.Second)
+	cache.Authenticate("revoked")
+	if upstream.calls["revoked"] != 2 {
+		t.Errorf("upstream called %d times once the negative TTL passed, want 2", upstream.calls["revoked"])
+	}
+}
+
+func TestCacheWithoutNegativeTTLAsksAgain(t *testing.T) {
+	cache, upstream, _ := newCache(CacheOptions{TTL: time.Hour})
+
+	cache.Authenticate("revoked")
+	cache.Authenticate("revoked")
+	if upstream.calls["revoked"] != 2 {
+		t.Errorf("upstream called %d times with negative caching off, want 2", upstream.calls["revoked"])
+	}
+}
+
+func TestCacheSkipsUpstreamFailures(t *testing.T) {
+	cache, upstream, _ := newCache(CacheOptions{TTL: time.Hour, NegativeTTL: time.Hour})
+
+	upstream.fail = true
+	if _, _, err := cache.Authenticate("a"); err == nil {
+		t.Fatal("Authenticate succeeded while the upstream was down")
+	}
+	upstream.fail = false
+	if userID, _, err := cache.Authenticate("a"); err != nil || userID != "user-a" {
+		t.Errorf("Authenticate after the outage = %q, %v; want user-a", userID, err)
+	}
+	if upstream.calls["a"] != 2 {
+		t.Errorf("upstream called %d times, want the failure left uncached", upstream.calls["a"])
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, upstream, _ := newCache(CacheOptions{TTL: time.Hour, MaxEntries: 2})
+
+	cache.Authenticate("a")
+	cache.Authenticate("b")
+	cache.Authenticate("a") // a is now more recent than b
+	cache.Authenticate("c") // evicts b
+
+	cache.Authenticate("a")
+	cache.Authenticate("c")
+	if upstream.calls["a"] != 1 || upstream.calls["c"] != 1 {
+		t.Errorf("upstream calls a=%d c=%d, want both still cached", upstream.calls["a"], upstream.calls["c"])
+	}
+	cache.Authenticate("b")
+	if upstream.calls["b"] != 2 {
+		t.Errorf("upstream called %d times for b, want it evicted", upstream.calls["b"])
+	}
+	if len(cache.entries) != 2 || cache.lru.Len() != 2 {
+		t.Errorf("cache holds %d entries, %d in the LRU list; want 2", len(cache.entries), cache.lru.Len())
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"hex/internal/application/auth"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// JWTOptions configures local token verification. HS256 needs Secret;
+// RS256 and ES256 need either a PEM public key file or a JWKS file.
+type JWTOptions struct {
+	Algorithm     string
+	Secret        []byte
+	PublicKeyFile string
+	JWKSFile      string
+	UserIDClaim   string
+	RoleClaim     string
+}
+
+type jwtAuthService struct {
+	parser      *jwt.Parser
+	keyFunc     jwt.Keyfunc
+	userIDClaim string
+	roleClaim   string
+}
+
+// NewJWTAuthService verifies signed JWTs without calling any other service.
+// Only the configured algorithm is accepted, so a token cannot pick a weaker
+// one, and tokens must carry an exp claim. Keys are read once, when the
+// service is built.
+func NewJWTAuthService(opts JWTOptions) (auth.AuthService, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = jwt.SigningMethodHS256.Alg()
+	}
+	if opts.UserIDClaim == "" {
+		opts.UserIDClaim = "user_id"
+	}
+	if opts.RoleClaim == "" {
+		opts.RoleClaim = "role"
+	}
+
+	var keyFunc jwt.Keyfunc
+	switch opts.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if len(opts.Secret) == 0 {
+			return nil, errors.New("HS256 requires a shared secret")
+		}
+		keyFunc = func(*jwt.Token) (interface{}, error) { return opts.Secret, nil }
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg():
+		keys, err := loadPublicKeys(opts)
+		if err != nil {
+			return nil, err
+		}
+		keyFunc = keys.lookup
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", opts.Algorithm)
+	}
+
+	return &jwtAuthService{
+		parser:      &jwt.Parser{ValidMethods: []string{opts.Algorithm}, UseJSONNumber: true},
+		keyFunc:     keyFunc,
+		userIDClaim: opts.UserIDClaim,
+		roleClaim:   opts.RoleClaim,
+	}, nil
+}
+
+func (s *jwtAuthService) Authenticate(token string) (string, string, error) {
+	token = strings.TrimSpace(token)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := s.parser.ParseWithClaims(token, claims, s.keyFunc); err != nil {
+		return "", "", fmt.Errorf("invalid token: %v", err)
+	}
+	// Parsing only checks exp when the token has one; a token without it
+	// would never expire
+	if !claims.VerifyExpiresAt(jwt.TimeFunc().Unix(), true) {
+		return "", "", errors.New("invalid token: missing or expired \"exp\" claim")
+	}
+
+	userID, err := claimString(claims, s.userIDClaim)
+	if err != nil {
+		return "", "", err
+	}
+	role, err := claimString(claims, s.roleClaim)
+	if err != nil {
+		return "", "", err
+	}
+	return userID, strings.ToLower(role), nil
+}
+
+func claimString(claims jwt.MapClaims, name string) (string, error) {
+	switch value := claims[name].(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case nil:
+		return "", fmt.Errorf("invalid token: missing %q claim", name)
+	default:
+		return "", fmt.Errorf("invalid token: unexpected type for %q claim", name)
+	}
+}
+
+// publicKeys holds verification keys by key ID. A key loaded from a PEM file
+// has an empty ID and matches any token.
+type publicKeys map[string]interface{}
+
+func (k publicKeys) lookup(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := k[kid]; ok {
+		return key, nil
+	}
+	if key, ok := k[""]; ok {
+		return key, nil
+	}
+	if kid == "" && len(k) == 1 {
+		for _, key := range k {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no key found for kid %q", kid)
+}
+
+func loadPublicKeys(opts JWTOptions) (publicKeys, error) {
+	switch {
+	case opts.PublicKeyFile != "":
+		data, err := os.ReadFile(opts.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		var key interface{}
+		if opts.Algorithm == jwt.SigningMethodRS256.Alg() {
+			key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		} else {
+			key, err = jwt.ParseECPublicKeyFromPEM(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return publicKeys{"": key}, nil
+	case opts.JWKSFile != "":
+		data, err := os.ReadFile(opts.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS: %w", err)
+		}
+		return parseJWKS(data, opts.Algorithm)
+	default:
+		return nil, fmt.Errorf("%s requires a public key file or a JWKS file", opts.Algorithm)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS keeps the signing keys that fit algorithm and skips the rest.
+func parseJWKS(data []byte, algorithm string) (publicKeys, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := publicKeys{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Alg != "" && k.Alg != algorithm {
+			continue
+		}
+
+		var (
+			key interface{}
+			err error
+		)
+		switch {
+		case k.Kty == "RSA" && algorithm == jwt.SigningMethodRS256.Alg():
+			key, err = k.rsaPublicKey()
+		case k.Kty == "EC" && algorithm == jwt.SigningMethodES256.Alg():
+			key, err = k.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no %s signing keys", algorithm)
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() {
+		return nil, errors.New("exponent too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if x.BitLen() > 256 || y.BitLen() > 256 {
+		return nil, errors.New("coordinates too large")
+	}
+	// Let crypto/ecdh reject points that are not on the curve
+	point := make([]byte, 65)
+	point[0] = 4
+	x.FillBytes(point[1:33])
+	y.FillBytes(point[33:])
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// validClaims are the claims of a token that expires in an hour.
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "42", "role": "Member", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return "Bearer " + signed
+}
+
+// writeFile writes data to a file in a directory removed after the test.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func publicKeyPEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func newJWTService(t *testing.T, opts JWTOptions) *jwtAuthService {
+	t.Helper()
+	service, err := NewJWTAuthService(opts)
+	if err != nil {
+		t.Fatalf("NewJWTAuthService: %v", err)
+	}
+	return service.(*jwtAuthService)
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestJWTAcceptsConfiguredAlgorithm(t *testing.T) {
+	rsaKey, ecKey := newRSAKey(t), newECKey(t)
+	tests := []struct {
+		name   string
+		opts   JWTOptions
+		method jwt.SigningMethod
+		key    interface{}
+	}{
+		{"HS256", JWTOptions{Secret: hmacSecret}, jwt.SigningMethodHS256, hmacSecret},
+		{"RS256", JWTOptions{Algorithm: "RS256", PublicKeyFile: writeFile(t, "rsa.pem", publicKeyPEM(t, &rsaKey.PublicKey))}, jwt.SigningMethodRS256, rsaKey},
+		{"ES256", JWTOptions{Algorithm: "ES256", PublicKeyFile: writeFile(t, "ec.pem", publicKeyPEM(t, &ecKey.PublicKey))}, jwt.SigningMethodES256, ecKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newJWTService(t, tt.opts)
+			userID, role, err := service.Authenticate(sign(t, tt.method, tt.key, "", validClaims()))
+			if err != nil || userID != "42" || role != "member" {
+				t.Errorf("Authenticate = %q, %q, %v; want 42, member", userID, role, err)
+			}
+		})
+	}
+}
+
+func TestJWTRejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	pemKey := publicKeyPEM(t, &rsaKey.PublicKey)
+	service := newJWTService(t, JWTOptions{Algorithm: "RS256", PublicKeyFile: writeFile(t, "rsa.pem", pemKey)})
+
+	// An attacker who knows the public key signs with it as an HMAC secret
+	forged := sign(t, jwt.SigningMethodHS256, pemKey, "", validClaims())
+	if _, _, err := service.Authenticate(forged); err == nil {
+		t.Error("Authenticate accepted an HS256 token signed with the RSA public key")
+	}
+
+	other := sign(t, jwt.SigningMethodES256, newECKey(t), "", validClaims())
+	if _, _, err := service.Authenticate(other); err == nil {
+		t.Error("Authenticate accepted an ES256 token on an RS256 service")
+	}
+}
+
+func TestJWTLooksUpKeyByKid(t *testing.T) {
+	first, second := newRSAKey(t), newRSAKey(t)
+	jwks := map[string][]map[string]string{"keys": {
+		{"kty": "RSA", "kid": "first", "use": "sig", "n": encodeBigInt(first.N), "e": encodeBigInt(big.NewInt(int64(first.E)))},
+		{"kty": "RSA", "kid": "second", "alg": "RS256", "n": encodeBigInt(second.N), "e": encodeBigInt(big.NewInt(int64(second.E)))},
+		{"kty": "RSA", "kid": "encryption", "use": "enc", "n": encodeBigInt(first.N), "e": encodeBigInt(big.NewInt(int64(first.E)))},
+	}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	service := newJWTService(t, JWTOptions{Algorithm: "RS256", JWKSFile: writeFile(t, "jwks.json", data)})
+
+	for kid, key := range map[string]*rsa.PrivateKey{"first": first, "second": second} {
+		if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodRS256, key, kid, validClaims())); err != nil {
+			t.Errorf("kid %q: %v", kid, err)
+		}
+	}
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodRS256, first, "second", validClaims())); err == nil {
+		t.Error("Authenticate accepted a token signed with a different key than its kid names")
+	}
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodRS256, first, "encryption", validClaims())); err == nil {
+		t.Error("Authenticate accepted a token naming a key not meant for signatures")
+	}
+}
+
+func TestJWTRejectsUnknownKid(t *testing.T) {
+	key := newECKey(t)
+	jwks := map[string][]map[string]string{"keys": {
+		{"kty": "EC", "kid": "known", "crv": "P-256", "x": encodeBigInt(key.X), "y": encodeBigInt(key.Y)},
+	}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	service := newJWTService(t, JWTOptions{Algorithm: "ES256", JWKSFile: writeFile(t, "jwks.json", data)})
+
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodES256, key, "known", validClaims())); err != nil {
+		t.Fatalf("known kid: %v", err)
+	}
+	_, _, err = service.Authenticate(sign(t, jwt.SigningMethodES256, key, "rotated", validClaims()))
+	if err == nil || !strings.Contains(err.Error(), "rotated") {
+		t.Errorf("unknown kid: err = %v, want one naming the kid", err)
+	}
+}
+
+func TestJWTRequiresUnexpiredExp(t *testing.T) {
+	service := newJWTService(t, JWTOptions{Secret: hmacSecret})
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", expired)); err == nil {
+		t.Error("Authenticate accepted an expired token")
+	}
+
+	forever := validClaims()
+	delete(forever, "exp")
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", forever)); err == nil {
+		t.Error("Authenticate accepted a token without exp")
+	}
+}
+
+func TestJWTCustomClaimNames(t *testing.T) {
+	service := newJWTService(t, JWTOptions{Secret: hmacSecret, UserIDClaim: "sub", RoleClaim: "https://example.com/role"})
+
+	claims := jwt.MapClaims{"sub": "7", "https://example.com/role": "ADMIN", "exp": time.Now().Add(time.Hour).Unix()}
+	userID, role, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims))
+	if err != nil || userID != "7" || role != "admin" {
+		t.Errorf("Authenticate = %q, %q, %v; want 7, admin", userID, role, err)
+	}
+
+	// The default names no longer count
+	if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", validClaims())); err == nil {
+		t.Error("Authenticate accepted a token with only the default claim names")
+	}
+}
+
+func TestJWTNonStringClaims(t *testing.T) {
+	service := newJWTService(t, JWTOptions{Secret: hmacSecret})
+
+	numeric := validClaims()
+	numeric["user_id"] = 9007199254740993
+	userID, _, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", numeric))
+	if err != nil || userID != "9007199254740993" {
+		t.Errorf("numeric user_id: Authenticate = %q, %v; want it exactly as sent", userID, err)
+	}
+
+	for name, value := range map[string]interface{}{"bool": true, "object": map[string]string{"name": "admin"}, "array": []string{"admin"}} {
+		claims := validClaims()
+		claims["role"] = value
+		if _, _, err := service.Authenticate(sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims)); err == nil {
+			t.Errorf("%s role: Authenticate accepted it", name)
+		}
+	}
+}
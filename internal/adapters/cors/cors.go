@@ -8,6 +8,7 @@ import (
 func ConfigureCORS(r *gin.Engine) {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type"}
+	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", "X-Request-ID"}
+	config.ExposeHeaders = []string{"X-Request-ID"}
 	r.Use(cors.New(config))
 }
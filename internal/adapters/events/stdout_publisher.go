@@ -0,0 +1,29 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"hex/internal/application/events"
+)
+
+// StdoutPublisher writes each message as a JSON object on a line of its own,
+// for development or for a log shipper to pick up.
+type StdoutPublisher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ events.Publisher = (*StdoutPublisher)(nil)
+
+func NewStdoutPublisher(w io.Writer) *StdoutPublisher {
+	return &StdoutPublisher{enc: json.NewEncoder(w)}
+}
+
+func (p *StdoutPublisher) Publish(_ context.Context, message events.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enc.Encode(message)
+}
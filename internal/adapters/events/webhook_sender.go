@@ -0,0 +1,67 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hex/internal/application/events"
+)
+
+// SignatureHeader carries the signature of a webhook delivery.
+const SignatureHeader = "X-Hex-Signature"
+
+// Sign returns the signature of body under secret, as sent in
+// SignatureHeader: "sha256=" followed by the hex HMAC-SHA256 of the body.
+// Receivers recompute it over the raw body and compare the two with
+// hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPWebhookSender POSTs webhook deliveries as JSON, signed in
+// SignatureHeader. X-Hex-Event and X-Hex-Delivery name the event type and
+// the delivery, which stays the same across retries so receivers can
+// deduplicate.
+type HTTPWebhookSender struct {
+	client *http.Client
+}
+
+var _ events.WebhookSender = (*HTTPWebhookSender)(nil)
+
+func NewHTTPWebhookSender(timeout time.Duration) *HTTPWebhookSender {
+	return &HTTPWebhookSender{client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPWebhookSender) Send(ctx context.Context, request events.WebhookRequest) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(request.Secret, request.Body))
+	req.Header.Set("X-Hex-Event", request.EventType)
+	req.Header.Set("X-Hex-Delivery", strconv.FormatUint(uint64(request.DeliveryID), 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
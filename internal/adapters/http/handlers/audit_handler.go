@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"hex/internal/adapters/http/middleware"
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFrom identifies who is making the request, for the audit log.
+func actorFrom(c *gin.Context) services.Actor {
+	principal, _ := middleware.PrincipalFrom(c)
+	return services.Actor{UserID: principal.UserID, Role: principal.Role, RequestID: middleware.RequestIDFrom(c)}
+}
+
+type AuditHandler struct {
+	service services.AuditService
+}
+
+func NewAuditHandler(service services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ListEntries pages through the audit log, newest first. It can be narrowed
+// to one entity with entity_type and entity_id, to one user with actor_id,
+// to one action, and to a time range with from and to. Times are RFC 3339 or
+// plain dates; a date in to includes that whole day.
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	entries, total, err := h.service.ListEntries(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"meta":    newPageMeta(query.Page, query.PageSize, total),
+	})
+}
+
+func parseAuditQuery(c *gin.Context) (repositories.AuditQuery, error) {
+	var query repositories.AuditQuery
+
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		return query, err
+	}
+	filter := repositories.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     c.Query("action"),
+	}
+	if filter.EntityType != "" && !slices.Contains(models.AuditEntities, filter.EntityType) {
+		return query, fmt.Errorf("entity_type must be one of %s", strings.Join(models.AuditEntities, ", "))
+	}
+	if value := c.Query("entity_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("entity_id must be a positive integer")
+		}
+		filter.EntityID = uint(id)
+	}
+	if value := c.Query("actor_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("actor_id must be a positive integer")
+		}
+		filter.ActorID = uint(id)
+	}
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseAuditTime(value)
+		if err != nil {
+			return query, fmt.Errorf("from %s", err)
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, isDate, err := parseAuditTime(value)
+		if err != nil {
+			return query, fmt.Errorf("to %s", err)
+		}
+		if isDate {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	return repositories.AuditQuery{Filter: filter, Page: page, PageSize: pageSize}, nil
+}
+
+// parseAuditTime reads an RFC 3339 timestamp or a YYYY-MM-DD date, reporting
+// which one it was.
+func parseAuditTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("must be an RFC 3339 time or a date in YYYY-MM-DD format")
+}
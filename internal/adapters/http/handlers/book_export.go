@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bookExport is how a book is exported. It carries the fields of bookBody
+// under the same names, so an export can be imported again.
+type bookExport struct {
+	ID uint `json:"id"`
+	bookBody
+	Availability uint       `json:"availability"`
+	Version      uint       `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+}
+
+func newBookExport(book models.Book) bookExport {
+	export := bookExport{
+		ID:           book.ID,
+		bookBody:     newBookBody(&book),
+		Availability: book.Availability,
+		Version:      book.Version,
+		CreatedAt:    book.CreatedAt,
+		UpdatedAt:    book.UpdatedAt,
+	}
+	if book.DeletedAt.Valid {
+		export.DeletedAt = &book.DeletedAt.Time
+	}
+	return export
+}
+
+var bookCSVHeader = []string{
+	"id", "title", "author", "authors", "publication_date", "genre", "isbn",
+	"publisher", "edition", "language", "page_count", "description",
+	"cover_url", "subjects", "loan_period_days", "availability", "version",
+	"created_at", "updated_at", "deleted_at",
+}
+
+func bookCSVRow(book models.Book) []string {
+	export := newBookExport(book)
+	return []string{
+		strconv.FormatUint(uint64(export.ID), 10),
+		export.Title,
+		export.Author,
+		strings.Join(export.Authors, csvListSeparator+" "),
+		export.PublicationDate,
+		export.Genre,
+		export.ISBN,
+		export.Publisher,
+		export.Edition,
+		export.Language,
+		strconv.FormatUint(uint64(export.PageCount), 10),
+		export.Description,
+		export.CoverURL,
+		strings.Join(export.Subjects, csvListSeparator+" "),
+		strconv.FormatUint(uint64(export.LoanPeriodDays), 10),
+		strconv.FormatUint(uint64(export.Availability), 10),
+		strconv.FormatUint(uint64(export.Version), 10),
+		exportTime(&export.CreatedAt),
+		exportTime(&export.UpdatedAt),
+		exportTime(export.DeletedAt),
+	}
+}
+
+// ExportBooks streams every book matching the list filters as CSV, NDJSON
+// or MARCXML, chosen with format.
+func (h *BookHandler) ExportBooks(c *gin.Context) {
+	format, ok := parseExportFormat(c, exportCSV, exportNDJSON, exportMARCXML)
+	if !ok {
+		return
+	}
+	filter, err := parseBookFilter(c)
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if !allowIncludeDeleted(c, filter) {
+		return
+	}
+
+	newWriter := func(w io.Writer) (recordWriter[models.Book], error) {
+		switch format {
+		case exportNDJSON:
+			return newNDJSONWriter(w, func(book models.Book) any { return newBookExport(book) }), nil
+		case exportMARCXML:
+			return newMARCWriter(w)
+		default:
+			return newCSVWriter(w, bookCSVHeader, bookCSVRow)
+		}
+	}
+	streamExport(c, "books", format, newWriter, func(fn func([]models.Book) error) error {
+		return h.service.ExportBooks(filter, fn)
+	})
+}
+
+// MARCXML, the MARC 21 slim schema, as exchanged between library systems.
+const marcNamespace = "http://www.loc.gov/MARC21/slim"
+
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// marcWriter writes a <collection> with one <record> per book.
+type marcWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+func newMARCWriter(w io.Writer) (recordWriter[models.Book], error) {
+	if _, err := io.WriteString(w, xml.Header+`<collection xmlns="`+marcNamespace+`">`+"\n"); err != nil {
+		return nil, err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("  ", "  ")
+	return &marcWriter{w: w, enc: enc}, nil
+}
+
+func (w *marcWriter) Write(book models.Book) error {
+	return w.enc.Encode(newMARCRecord(book))
+}
+
+func (w *marcWriter) Flush() error {
+	return w.enc.Flush()
+}
+
+func (w *marcWriter) Close() error {
+	if err := w.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "\n</collection>\n")
+	return err
+}
+
+// newMARCRecord describes a book as a MARC 21 bibliographic record for a
+// printed monograph.
+func newMARCRecord(book models.Book) marcRecord {
+	// Leader/05 record status: n new, d deleted
+	status := "n"
+	if book.DeletedAt.Valid {
+		status = "d"
+	}
+	published := time.Time(book.PublicationDate)
+
+	record := marcRecord{
+		Leader: "00000" + status + "am a2200000 i 4500",
+		ControlFields: []marcControlField{
+			{Tag: "001", Value: strconv.FormatUint(uint64(book.ID), 10)},
+			{Tag: "005", Value: book.UpdatedAt.UTC().Format("20060102150405.0")},
+			{Tag: "008", Value: marcFixedData(book.CreatedAt, published, book.Language)},
+		},
+	}
+	field := func(tag, ind1, ind2 string, subfields ...marcSubfield) {
+		var kept []marcSubfield
+		for _, subfield := range subfields {
+			if subfield.Value != "" {
+				kept = append(kept, subfield)
+			}
+		}
+		if len(kept) > 0 {
+			record.DataFields = append(record.DataFields, marcDataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+		}
+	}
+
+	if book.ISBN != nil {
+		field("020", " ", " ", marcSubfield{"a", *book.ISBN})
+	}
+	authors := book.Authors
+	if len(authors) == 0 && book.Author != "" {
+		authors = []models.Author{{Name: book.Author}}
+	}
+	titleInd1 := "0"
+	if len(authors) > 0 {
+		field("100", "1", " ", marcSubfield{"a", authors[0].Name})
+		titleInd1 = "1"
+	}
+	field("245", titleInd1, "0", marcSubfield{"a", book.Title})
+	field("250", " ", " ", marcSubfield{"a", book.Edition})
+	field("264", " ", "1", marcSubfield{"b", book.Publisher}, marcSubfield{"c", published.Format("2006")})
+	if book.PageCount > 0 {
+		field("300", " ", " ", marcSubfield{"a", fmt.Sprintf("%d pages", book.PageCount)})
+	}
+	field("520", " ", " ", marcSubfield{"a", book.Description})
+	field("546", " ", " ", marcSubfield{"a", book.Language})
+	for _, subject := range book.Subjects {
+		field("650", " ", "4", marcSubfield{"a", subject.Name})
+	}
+	field("655", " ", "4", marcSubfield{"a", book.Genre})
+	for _, author := range authors[min(1, len(authors)):] {
+		field("700", "1", " ", marcSubfield{"a", author.Name})
+	}
+	if book.CoverURL != "" {
+		field("856", "4", "2", marcSubfield{"3", "Cover image"}, marcSubfield{"u", book.CoverURL})
+	}
+	return record
+}
+
+// marcFixedData builds the 40 characters of field 008. Positions not known
+// for our books are left blank or marked undetermined.
+func marcFixedData(entered, published time.Time, language string) string {
+	// 35-37 take a MARC language code; only three-letter tags can be one
+	code := "und"
+	if len(language) == 3 {
+		code = strings.ToLower(language)
+	}
+	return entered.UTC().Format("060102") + // 00-05 date entered
+		"s" + published.Format("2006") + "    " + // 06-14 single known date
+		"xx " + // 15-17 place of publication unknown
+		strings.Repeat(" ", 17) + // 18-34 material specific details
+		code + // 35-37 language
+		" " + // 38 not modified
+		"d" // 39 cataloging source: other
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
-	"hex/internal/application/auth"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hex/internal/adapters/http/middleware"
+	appauth "hex/internal/application/auth"
+	"hex/internal/application/repositories"
 	"hex/internal/application/services"
 	"hex/pkg/models"
 	"net/http"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,177 +21,395 @@ import (
 )
 
 type BookHandler struct {
-	service     *services.BookService
-	authService auth.AuthService
+	service *services.BookService
 }
 
-func NewBookHandler(service *services.BookService, authService auth.AuthService) *BookHandler {
-	return &BookHandler{service: service, authService: authService}
+func NewBookHandler(service *services.BookService) *BookHandler {
+	return &BookHandler{service: service}
 }
 
+// bookBody is the JSON accepted when creating or replacing a book. Either
+// author or authors is required; authors wins when both are given.
+type bookBody struct {
+	Title           string   `json:"title" binding:"required"`
+	Author          string   `json:"author"`
+	Authors         []string `json:"authors"`
+	PublicationDate string   `json:"publication_date" binding:"required"`
+	Genre           string   `json:"genre"`
+	ISBN            string   `json:"isbn"`
+	Publisher       string   `json:"publisher"`
+	Edition         string   `json:"edition"`
+	Language        string   `json:"language"`
+	PageCount       uint     `json:"page_count"`
+	Description     string   `json:"description"`
+	CoverURL        string   `json:"cover_url"`
+	Subjects        []string `json:"subjects"`
+	LoanPeriodDays  uint     `json:"loan_period_days"`
+}
+
+// apply copies the body onto book, replacing every field it covers.
+func (b bookBody) apply(book *models.Book) error {
+	layout := "2006-01-02"
+	parsedDate, err := time.Parse(layout, b.PublicationDate)
+	if err != nil {
+		return &services.ValidationError{Field: "publication_date", Message: "publication_date must be a date in YYYY-MM-DD format"}
+	}
+
+	book.Title = b.Title
+	book.Author = b.Author
+	book.PublicationDate = datatypes.Date(parsedDate)
+	book.Genre = b.Genre
+	book.Publisher = b.Publisher
+	book.Edition = b.Edition
+	book.Language = b.Language
+	book.PageCount = b.PageCount
+	book.Description = b.Description
+	book.CoverURL = b.CoverURL
+	book.LoanPeriodDays = b.LoanPeriodDays
+
+	book.ISBN = nil
+	if b.ISBN != "" {
+		isbn := b.ISBN
+		book.ISBN = &isbn
+	}
+	book.Authors = nil
+	for _, name := range b.Authors {
+		book.Authors = append(book.Authors, models.Author{Name: name})
+	}
+	book.Subjects = nil
+	for _, name := range b.Subjects {
+		book.Subjects = append(book.Subjects, models.Subject{Name: name})
+	}
+	return nil
+}
+
+// newBookBody is the inverse of apply: the body that would recreate book.
+func newBookBody(book *models.Book) bookBody {
+	body := bookBody{
+		Title:           book.Title,
+		Author:          book.Author,
+		PublicationDate: time.Time(book.PublicationDate).Format("2006-01-02"),
+		Genre:           book.Genre,
+		Publisher:       book.Publisher,
+		Edition:         book.Edition,
+		Language:        book.Language,
+		PageCount:       book.PageCount,
+		Description:     book.Description,
+		CoverURL:        book.CoverURL,
+		LoanPeriodDays:  book.LoanPeriodDays,
+	}
+	if book.ISBN != nil {
+		body.ISBN = *book.ISBN
+	}
+	for _, author := range book.Authors {
+		body.Authors = append(body.Authors, author.Name)
+	}
+	for _, subject := range book.Subjects {
+		body.Subjects = append(body.Subjects, subject.Name)
+	}
+	return body
+}
+
+// bookBodyFields lists the JSON names of bookBody, the members a merge
+// patch may touch.
+var bookBodyFields = func() []string {
+	var names []string
+	fields := reflect.VisibleFields(reflect.TypeOf(bookBody{}))
+	for _, field := range fields {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}()
+
+// mergeBook applies a JSON Merge Patch, already decoded into patch, to the
+// book's bookBody representation and copies the result back onto book.
+// Members left out of the patch keep their current values.
+func mergeBook(book *models.Book, patch map[string]any) error {
+	current, err := json.Marshal(newBookBody(book))
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+	// A new byline replaces the authors unless they are patched too
+	if _, ok := patch["authors"]; !ok && patch["author"] != nil {
+		delete(doc, "authors")
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return err
+	}
+
+	var body bookBody
+	if err := json.Unmarshal(merged, &body); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &services.ValidationError{Field: typeErr.Field, Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type)}
+		}
+		return err
+	}
+	return body.apply(book)
+}
+
+// bookETag identifies one version of a book.
+func bookETag(book *models.Book) string {
+	return fmt.Sprintf(`"%d-%d"`, book.ID, book.Version)
+}
+
+// bookDetailsETag extends bookETag with the live counts shown by GetBook,
+// which change without the book itself being updated. ifMatchVersion
+// ignores the extension, so either tag may be sent back in If-Match.
+func bookDetailsETag(details *services.BookDetails) string {
+	return fmt.Sprintf(`"%d-%d.%d.%d"`, details.ID, details.Version, details.Availability, details.HoldQueueLength)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison the header calls for.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion reads If-Match for the book with the given ID and returns
+// the version the client expects, or 0 when any version will do. ok is false
+// when the header can never match, such as an ETag for another book.
+func ifMatchVersion(c *gin.Context, id string) (version uint, ok bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+
+	tag, found := strings.CutPrefix(value, `"`+id+"-")
+	if !found {
+		return 0, false
+	}
+	tag, found = strings.CutSuffix(tag, `"`)
+	if !found {
+		return 0, false
+	}
+	tag, _, _ = strings.Cut(tag, ".")
+	n, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+func respondPreconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, errorBody{Code: "precondition_failed", Message: "If-Match does not match the current version of the book"})
+}
+
+// CreateBook adds a book with availability copies on the shelf, or one
+// copy when availability is left out.
 func (h *BookHandler) CreateBook(c *gin.Context) {
 	var body struct {
-		Title           string `json:"title" binding:"required"`
-		Author          string `json:"author" binding:"required"`
-		PublicationDate string `json:"publication_date" binding:"required"`
-		Genre           string `json:"genre"`
-		Availability    uint   `json:"availability"`
+		bookBody
+		Availability *uint `json:"availability"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	layout := "2006-01-02"
-	parsedDate, err := time.Parse(layout, body.PublicationDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid publication date format"})
+	book := models.Book{Availability: 1}
+	if body.Availability != nil {
+		book.Availability = *body.Availability
+	}
+	if err := body.apply(&book); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	book := models.Book{
-		Title:           body.Title,
-		Author:          body.Author,
-		PublicationDate: datatypes.Date(parsedDate),
-		Genre:           body.Genre,
-		Availability:    body.Availability,
+	if err := h.service.CreateBook(&book, actorFrom(c)); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	token := c.GetHeader("Authorization")
-	_, role, err := h.authService.Authenticate(token)
+	c.Header("ETag", bookETag(&book))
+	c.JSON(http.StatusCreated, book)
+}
+
+func (h *BookHandler) ViewAllBooks(c *gin.Context) {
+	query, err := parseBookQuery(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondBadRequest(c, err.Error())
+		return
+	}
+	if !allowIncludeDeleted(c, query.Filter) {
 		return
 	}
 
-	if role != "admin" && role != "librarian" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: only admins and librarians can create books"})
+	books, total, err := h.service.ListBooks(query)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"books": books,
+		"meta":  newPageMeta(query.Page, query.PageSize, total),
+	})
+}
 
-	if err := h.service.CreateBook(&book); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// GetBook shows one book with its live availability and hold queue. It can
+// be revalidated with If-None-Match, which answers 304 when nothing shown
+// has changed.
+func (h *BookHandler) GetBook(c *gin.Context) {
+	details, err := h.service.GetBookDetails(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, book)
+	etag := bookDetailsETag(details)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", details.UpdatedAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, details)
 }
 
-func (h *BookHandler) ViewAllBooks(c *gin.Context) {
-	token := c.GetHeader("Authorization")
-	_, _, err := h.authService.Authenticate(token)
+// allowIncludeDeleted answers 403 itself when a non-admin asks for deleted
+// books.
+func allowIncludeDeleted(c *gin.Context, filter repositories.BookFilter) bool {
+	if filter.IncludeDeleted {
+		if principal, _ := middleware.PrincipalFrom(c); !principal.HasRole(appauth.RoleAdmin) {
+			c.JSON(http.StatusForbidden, errorBody{Code: "forbidden", Message: "include_deleted is only available to admins"})
+			return false
+		}
+	}
+	return true
+}
+
+func (h *BookHandler) SearchBooks(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		respondBadRequest(c, "q is required")
+		return
+	}
+
+	limit, err := parseLimit(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondBadRequest(c, err.Error())
 		return
 	}
 
-	books, err := h.service.ViewAllBooks()
+	matches, err := h.service.SearchBooks(text, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"books": books})
+	c.JSON(http.StatusOK, gin.H{"results": matches})
 }
 
+// UpdateBook replaces every editable field of a book. Copies, and so
+// availability, are managed separately and never change here.
 func (h *BookHandler) UpdateBook(c *gin.Context) {
 	id := c.Param("id")
-
-	var body struct {
-		Title           string `json:"title" binding:"required"`
-		Author          string `json:"author" binding:"required"`
-		PublicationDate string `json:"publication_date" binding:"required"`
-		Genre           string `json:"genre"`
-		Availability    uint   `json:"availability"`
+	version, ok := ifMatchVersion(c, id)
+	if !ok {
+		respondPreconditionFailed(c)
+		return
 	}
 
+	var body bookBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	token := c.GetHeader("Authorization")
-	_, role, err := h.authService.Authenticate(token)
+	book, err := h.service.UpdateBook(id, version, actorFrom(c), body.apply)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	if role != "admin" && role != "librarian" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: only admins and librarians can update books"})
+	c.Header("ETag", bookETag(book))
+	c.JSON(http.StatusOK, book)
+}
+
+// PatchBook applies a JSON Merge Patch to a book, touching only the fields
+// the patch names. A null clears the field.
+func (h *BookHandler) PatchBook(c *gin.Context) {
+	id := c.Param("id")
+	version, ok := ifMatchVersion(c, id)
+	if !ok {
+		respondPreconditionFailed(c)
 		return
 	}
 
-	existingBook, err := h.service.GetBookByID(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if contentType := c.ContentType(); contentType != "application/merge-patch+json" && contentType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, errorBody{
+			Code:    "unsupported_media_type",
+			Message: "Content-Type must be application/merge-patch+json",
+		})
 		return
 	}
 
-	if existingBook == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	var patch map[string]any
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		respondBadRequest(c, "body must be a JSON object")
 		return
 	}
-
-	existingBook.Title = body.Title
-	existingBook.Author = body.Author
-	existingBook.Genre = body.Genre
-	existingBook.Availability = body.Availability
-
-	if body.PublicationDate != "" {
-		layout := "2006-01-02"
-		parsedDate, err := time.Parse(layout, body.PublicationDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid publication date format"})
+	for name := range patch {
+		if !slices.Contains(bookBodyFields, name) {
+			respondError(c, &services.ValidationError{Field: name, Message: "unknown field " + name})
 			return
 		}
-		existingBook.PublicationDate = datatypes.Date(parsedDate)
 	}
 
-	if err := h.service.UpdateBook(existingBook); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	book, err := h.service.UpdateBook(id, version, actorFrom(c), func(book *models.Book) error {
+		return mergeBook(book, patch)
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, existingBook)
+	c.Header("ETag", bookETag(book))
+	c.JSON(http.StatusOK, book)
 }
 
+// DeleteBook marks a book deleted. It can be undone with RestoreBook.
 func (h *BookHandler) DeleteBook(c *gin.Context) {
-	idStr := c.Param("id")
-
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+	if err := h.service.DeleteBook(c.Param("id"), actorFrom(c)); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	token := c.GetHeader("Authorization")
-	_, role, err := h.authService.Authenticate(token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if role != "admin" && role != "librarian" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: only admins and librarians can delete books"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Book deleted successfully"})
+}
 
-	book, err := h.service.GetBookByID(strconv.FormatUint(uint64(id), 10))
+func (h *BookHandler) RestoreBook(c *gin.Context) {
+	book, err := h.service.RestoreBook(c.Param("id"), actorFrom(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	if book == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
-		return
-	}
+	c.Header("ETag", bookETag(book))
+	c.JSON(http.StatusOK, book)
+}
 
-	if err := h.service.DeleteBook(strconv.FormatUint(uint64(id), 10)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// PurgeBook permanently removes a deleted book after archiving its
+// borrowing history.
+func (h *BookHandler) PurgeBook(c *gin.Context) {
+	archived, err := h.service.PurgeBook(c.Param("id"), actorFrom(c))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Book deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Book purged successfully", "archived_records": archived})
 }
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"gorm.io/datatypes"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version uint
+		ok      bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"7-3"`, 3, true},
+		{`"8-3"`, 0, false},
+		{`"7-0"`, 0, false},
+		{`7-3`, 0, false},
+		{`"7-three"`, 0, false},
+		{`"7-3.1.0"`, 3, true},
+	}
+	for _, tt := range tests {
+		c := newQueryContext("/books/7")
+		c.Request.Header.Set("If-Match", tt.header)
+		if version, ok := ifMatchVersion(c, "7"); version != tt.version || ok != tt.ok {
+			t.Errorf("If-Match %s: version %d, ok %v; want %d, %v", tt.header, version, ok, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := bookDetailsETag(&services.BookDetails{Book: models.Book{Version: 3, Availability: 1}, HoldQueueLength: 2})
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"*", true},
+		{etag, true},
+		{"W/" + etag, true},
+		{`"1-1.0.0", ` + etag, true},
+		{`"0-3.1.1"`, false},
+		{`"0-3"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%s, %s) = %v, want %v", tt.header, etag, got, tt.want)
+		}
+	}
+}
+
+func TestMergeBookKeepsUnsentFields(t *testing.T) {
+	book := &models.Book{
+		Title:           "The Left Hand of Darkness",
+		Author:          "Ursula K. Le Guin",
+		Genre:           "Science Fiction",
+		PublicationDate: datatypes.Date(time.Date(1969, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Availability:    4,
+	}
+
+	if err := mergeBook(book, map[string]any{"title": "The Dispossessed", "genre": nil}); err != nil {
+		t.Fatalf("mergeBook: %v", err)
+	}
+	if book.Title != "The Dispossessed" || book.Genre != "" {
+		t.Errorf("title %q, genre %q; want the patched title and genre cleared", book.Title, book.Genre)
+	}
+	if book.Author != "Ursula K. Le Guin" || time.Time(book.PublicationDate).Year() != 1969 || book.Availability != 4 {
+		t.Errorf("unpatched fields changed: %+v", book)
+	}
+
+	var validation *services.ValidationError
+	if err := mergeBook(book, map[string]any{"page_count": "many"}); !errors.As(err, &validation) || validation.Field != "page_count" {
+		t.Errorf("mergeBook with a string page count: err = %v, want a ValidationError on page_count", err)
+	}
+}
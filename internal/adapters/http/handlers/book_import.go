@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize caps an import upload.
+const maxImportSize = 10 << 20
+
+// Import formats.
+const (
+	importCSV    = "csv"
+	importNDJSON = "ndjson"
+)
+
+// importFields are the fields a row may set: those of bookBody plus the
+// number of copies a new book starts with.
+var importFields = append(slices.Clone(bookBodyFields), "availability")
+
+// csvListSeparator splits authors and subjects within one CSV cell.
+const csvListSeparator = ";"
+
+// importRecord is one row of an import file keyed by its column names.
+type importRecord struct {
+	line   int
+	values map[string]any
+}
+
+// ImportBooks creates or updates books from a CSV or NDJSON file, sent as
+// the "file" part of a multipart form or as the whole request body. CSV
+// files need a header row; authors and subjects are separated by
+// semicolons. The optional mapping parameter is a JSON object naming, for
+// each book field, the column to read it from; unmapped fields are read from
+// the column of the same name. With dry_run=true nothing is saved.
+func (h *BookHandler) ImportBooks(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			respondBadRequest(c, "dry_run must be true or false")
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	data, format, err := readImportUpload(c)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, errorBody{
+				Code:    "too_large",
+				Message: fmt.Sprintf("import files are limited to %d bytes", maxImportSize),
+			})
+			return
+		}
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	mapping, err := parseImportMapping(c.PostForm("mapping"), c.Query("mapping"))
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	var records []importRecord
+	switch format {
+	case importCSV:
+		records, err = readCSVRecords(data)
+	case importNDJSON:
+		records, err = readNDJSONRecords(data)
+	}
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+
+	rows := make([]services.ImportRow, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, newImportRow(record, mapping, format))
+	}
+
+	report, err := h.service.ImportBooks(rows, dryRun, actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// readImportUpload returns the uploaded file and its format, taken from the
+// format parameter, the content type or the file name, in that order.
+func readImportUpload(c *gin.Context) ([]byte, string, error) {
+	var (
+		reader      io.Reader = c.Request.Body
+		contentType           = c.ContentType()
+		name        string
+	)
+	if strings.HasPrefix(contentType, "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, "", err
+			}
+			return nil, "", fmt.Errorf("file is required")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		reader = file
+		contentType, _, _ = mime.ParseMediaType(header.Header.Get("Content-Type"))
+		name = header.Filename
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = importFormat(contentType, name)
+	}
+	if format != importCSV && format != importNDJSON {
+		return nil, "", fmt.Errorf("format must be %s or %s", importCSV, importNDJSON)
+	}
+
+	data, err := io.ReadAll(reader)
+	return data, format, err
+}
+
+func importFormat(contentType, name string) string {
+	switch contentType {
+	case "text/csv":
+		return importCSV
+	case "application/x-ndjson", "application/jsonl":
+		return importNDJSON
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return importCSV
+	case ".ndjson", ".jsonl":
+		return importNDJSON
+	}
+	return ""
+}
+
+// parseImportMapping reads the mapping from whichever of the form and query
+// values is set and fills in the identity mapping for the other fields.
+func parseImportMapping(values ...string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return nil, fmt.Errorf("mapping must be a JSON object of field names to column names")
+		}
+		break
+	}
+	for field := range mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("cannot map unknown field %q", field)
+		}
+	}
+	for _, field := range importFields {
+		if _, ok := mapping[field]; !ok {
+			mapping[field] = field
+		}
+	}
+	return mapping, nil
+}
+
+func readCSVRecords(data []byte) ([]importRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV header row is missing: %w", err)
+	}
+	reader.FieldsPerRecord = len(header)
+
+	var records []importRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]any, len(header))
+		for i, column := range header {
+			values[strings.TrimSpace(column)] = fields[i]
+		}
+		records = append(records, importRecord{line: line, values: values})
+	}
+}
+
+func readNDJSONRecords(data []byte) ([]importRecord, error) {
+	var records []importRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxImportSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var values map[string]any
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			// Keep the line so the report can reject it
+			values = nil
+		}
+		records = append(records, importRecord{line: line, values: values})
+	}
+	return records, scanner.Err()
+}
+
+// newImportRow turns a record into a row that merges the mapped values onto
+// a book, the way PATCH does. Empty CSV cells leave the field alone.
+func newImportRow(record importRecord, mapping map[string]string, format string) services.ImportRow {
+	if record.values == nil {
+		return services.ImportRow{Line: record.line, Apply: func(*models.Book) error {
+			return fmt.Errorf("line is not a JSON object")
+		}}
+	}
+
+	patch := map[string]any{}
+	for field, column := range mapping {
+		value, ok := record.values[column]
+		if !ok || value == nil {
+			continue
+		}
+		if format == importCSV {
+			cell := strings.TrimSpace(value.(string))
+			if cell == "" {
+				continue
+			}
+			value = csvValue(field, cell)
+		}
+		patch[field] = value
+	}
+
+	row := services.ImportRow{Line: record.line}
+	if value, ok := patch["availability"]; ok {
+		delete(patch, "availability")
+		copies, ok := value.(float64)
+		if !ok || copies < 0 || copies != float64(uint(copies)) {
+			row.Apply = func(*models.Book) error {
+				return &services.ValidationError{Field: "availability", Message: "availability must be a non-negative integer"}
+			}
+			return row
+		}
+		n := uint(copies)
+		row.Copies = &n
+	}
+	row.Apply = func(book *models.Book) error {
+		return mergeBook(book, patch)
+	}
+	return row
+}
+
+// csvValue converts a CSV cell to the JSON type of field. Cells that do not
+// convert are kept as text so that mergeBook reports them.
+func csvValue(field, cell string) any {
+	switch field {
+	case "authors", "subjects":
+		var names []any
+		for _, name := range strings.Split(cell, csvListSeparator) {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	case "page_count", "loan_period_days", "availability":
+		if n, err := strconv.ParseUint(cell, 10, 32); err == nil {
+			return float64(n)
+		}
+	}
+	return cell
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageMeta describes where a page sits in the full result set.
+type pageMeta struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+func newPageMeta(page, pageSize int, total int64) pageMeta {
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+	return pageMeta{Page: page, PageSize: pageSize, Total: total, TotalPages: totalPages}
+}
+
+// parsePage reads page and page_size, applying the defaults and the cap.
+func parsePage(c *gin.Context) (int, int, error) {
+	page, pageSize := 1, defaultPageSize
+	if value := c.Query("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+		page = n
+	}
+	if value := c.Query("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			return 0, 0, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+		pageSize = n
+	}
+	return page, pageSize, nil
+}
+
+// parseLimit reads limit for endpoints that return a single ranked page.
+func parseLimit(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return n, nil
+}
+
+// parseBookFilter reads the filter parameters shared by every book listing.
+func parseBookFilter(c *gin.Context) (repositories.BookFilter, error) {
+	filter := repositories.BookFilter{
+		Genre:       c.Query("genre"),
+		Author:      c.Query("author"),
+		Publisher:   c.Query("publisher"),
+		Description: c.Query("description"),
+		Edition:     c.Query("edition"),
+		Language:    c.Query("language"),
+		Subject:     c.Query("subject"),
+	}
+
+	if value := c.Query("isbn"); value != "" {
+		isbn, err := models.NormalizeISBN(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.ISBN = isbn
+	}
+	if value := c.Query("has_cover"); value != "" {
+		hasCover, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("has_cover must be true or false")
+		}
+		filter.HasCover = &hasCover
+	}
+	if value := c.Query("min_pages"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("min_pages must be a non-negative integer")
+		}
+		minPages := uint(n)
+		filter.MinPages = &minPages
+	}
+	if value := c.Query("max_pages"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("max_pages must be a non-negative integer")
+		}
+		maxPages := uint(n)
+		filter.MaxPages = &maxPages
+	}
+
+	if value := c.Query("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("include_deleted must be true or false")
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+
+	if value := c.Query("available"); value != "" {
+		available, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("available must be true or false")
+		}
+		filter.Available = &available
+	}
+
+	layout := "2006-01-02"
+	if value := c.Query("published_from"); value != "" {
+		from, err := time.Parse(layout, value)
+		if err != nil {
+			return filter, fmt.Errorf("published_from must be a date in YYYY-MM-DD format")
+		}
+		filter.PublishedFrom = &from
+	}
+	if value := c.Query("published_to"); value != "" {
+		to, err := time.Parse(layout, value)
+		if err != nil {
+			return filter, fmt.Errorf("published_to must be a date in YYYY-MM-DD format")
+		}
+		filter.PublishedTo = &to
+	}
+
+	return filter, nil
+}
+
+// parseBookSort reads sort as a comma-separated list of fields, each
+// optionally prefixed with "-" for descending order, e.g. "title,-genre".
+func parseBookSort(value string) ([]repositories.BookSort, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var sorts []repositories.BookSort
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		sort := repositories.BookSort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !slices.Contains(repositories.BookSortFields, sort.Field) {
+			return nil, fmt.Errorf("cannot sort by %q, expected one of %s", sort.Field, strings.Join(repositories.BookSortFields, ", "))
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+func parseBookQuery(c *gin.Context) (repositories.BookQuery, error) {
+	var query repositories.BookQuery
+
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		return query, err
+	}
+	filter, err := parseBookFilter(c)
+	if err != nil {
+		return query, err
+	}
+	sorts, err := parseBookSort(c.Query("sort"))
+	if err != nil {
+		return query, err
+	}
+
+	return repositories.BookQuery{Filter: filter, Sort: sorts, Page: page, PageSize: pageSize}, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newQueryContext(target string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	return c
+}
+
+func TestParseBookQuery(t *testing.T) {
+	c := newQueryContext("/books?page=3&page_size=5&genre=Fantasy&available=true&published_from=1968-01-01&sort=title,-publication_date")
+
+	query, err := parseBookQuery(c)
+	if err != nil {
+		t.Fatalf("parseBookQuery: %v", err)
+	}
+	if query.Page != 3 || query.PageSize != 5 || query.Offset() != 10 {
+		t.Errorf("page %d of %d, offset %d; want page 3 of 5, offset 10", query.Page, query.PageSize, query.Offset())
+	}
+	if query.Filter.Genre != "Fantasy" || query.Filter.Available == nil || !*query.Filter.Available {
+		t.Errorf("filter = %+v, want genre Fantasy and available", query.Filter)
+	}
+	if from := query.Filter.PublishedFrom; from == nil || !from.Equal(time.Date(1968, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published from %v, want 1968-01-01", from)
+	}
+	want := []repositories.BookSort{{Field: "title"}, {Field: "publication_date", Desc: true}}
+	if len(query.Sort) != len(want) || query.Sort[0] != want[0] || query.Sort[1] != want[1] {
+		t.Errorf("sort = %+v, want %+v", query.Sort, want)
+	}
+}
+
+func TestParseBookQueryDefaults(t *testing.T) {
+	query, err := parseBookQuery(newQueryContext("/books"))
+	if err != nil {
+		t.Fatalf("parseBookQuery: %v", err)
+	}
+	if query.Page != 1 || query.PageSize != defaultPageSize || query.Sort != nil {
+		t.Errorf("query = %+v, want page 1 of %d, unsorted", query, defaultPageSize)
+	}
+}
+
+func TestParseBookQueryRejectsBadParameters(t *testing.T) {
+	for _, target := range []string{
+		"/books?page=0",
+		"/books?page_size=101",
+		"/books?available=maybe",
+		"/books?published_to=01/02/1970",
+		"/books?sort=isbn",
+	} {
+		if _, err := parseBookQuery(newQueryContext(target)); err == nil {
+			t.Errorf("parseBookQuery(%s) accepted it", target)
+		}
+	}
+}
+
+func TestPageMetaRoundsUp(t *testing.T) {
+	if meta := newPageMeta(2, 20, 41); meta.TotalPages != 3 {
+		t.Errorf("41 books in pages of 20 make %d pages, want 3", meta.TotalPages)
+	}
+	if meta := newPageMeta(1, 20, 0); meta.TotalPages != 0 {
+		t.Errorf("no books make %d pages, want 0", meta.TotalPages)
+	}
+}
@@ -2,21 +2,23 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
-	"hex/internal/application/auth"
+	"hex/internal/adapters/http/middleware"
+	"hex/internal/application/repositories"
 	"hex/internal/application/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type BorrowingHandler struct {
-	service     services.BorrowingService
-	authService auth.AuthService
+	service services.BorrowingService
 }
 
-func NewBorrowingHandler(service services.BorrowingService, authService auth.AuthService) *BorrowingHandler {
-	return &BorrowingHandler{service: service, authService: authService}
+func NewBorrowingHandler(service services.BorrowingService) *BorrowingHandler {
+	return &BorrowingHandler{service: service}
 }
 
 func (h *BorrowingHandler) BorrowBook(c *gin.Context) {
@@ -25,29 +27,12 @@ func (h *BorrowingHandler) BorrowBook(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	userID, role, err := h.authService.Authenticate(c.GetHeader("Authorization"))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if role != "member" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: only members can borrow books"})
-		return
-	}
-
-	memberID, err := strconv.ParseUint(userID, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := h.service.BorrowBook(body.BookID, uint(memberID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.BorrowBook(body.BookID, actorFrom(c)); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -60,79 +45,93 @@ func (h *BorrowingHandler) ReturnBook(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	userID, role, err := h.authService.Authenticate(c.GetHeader("Authorization"))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	if err := h.service.ReturnBook(body.BorrowingRecordID, actorFrom(c)); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if role != "member" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: only members can return books"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Book returned successfully"})
+}
 
-	memberID, err := strconv.ParseUint(userID, 10, 64)
+func (h *BorrowingHandler) RenewBorrowing(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondBadRequest(c, "Invalid borrowing record ID")
 		return
 	}
 
-	if err := h.service.ReturnBook(body.BorrowingRecordID, uint(memberID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	borrowingRecord, err := h.service.RenewBorrowing(uint(id), actorFrom(c))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Book returned successfully"})
+	c.JSON(http.StatusOK, borrowingRecord)
 }
 
 func (h *BorrowingHandler) GetMyBorrowings(c *gin.Context) {
-	userID, role, err := h.authService.Authenticate(c.GetHeader("Authorization"))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	status, ok := parseBorrowingStatus(c)
+	if !ok {
 		return
 	}
 
-	if role != "member" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: only members can view their borrowings"})
+	principal, _ := middleware.PrincipalFrom(c)
+
+	borrowingRecords, err := h.service.GetMyBorrowings(principal.UserID, status)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	memberID, err := strconv.ParseUint(userID, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, borrowingRecords)
+}
+
+func (h *BorrowingHandler) GetAllBorrowingRecords(c *gin.Context) {
+	filter, ok := parseBorrowingFilter(c)
+	if !ok {
 		return
 	}
 
-	borrowingRecords, err := h.service.GetMyBorrowings(uint(memberID))
+	borrowingRecords, err := h.service.GetAllBorrowingRecords(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, borrowingRecords)
 }
 
-func (h *BorrowingHandler) GetAllBorrowingRecords(c *gin.Context) {
-	_, role, err := h.authService.Authenticate(c.GetHeader("Authorization"))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+// parseBorrowingFilter reads the filters of the staff listing of borrowing
+// records, answering 400 itself when one is invalid.
+func parseBorrowingFilter(c *gin.Context) (repositories.BorrowingFilter, bool) {
+	status, ok := parseBorrowingStatus(c)
+	if !ok {
+		return repositories.BorrowingFilter{}, false
 	}
+	filter := repositories.BorrowingFilter{Status: status}
 
-	if role != "admin" && role != "librarian" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: only admins and librarians can view all borrowing records"})
-		return
+	if value := c.Query("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			respondBadRequest(c, "overdue must be true or false")
+			return filter, false
+		}
+		filter.Overdue = &overdue
 	}
+	return filter, true
+}
 
-	borrowingRecords, err := h.service.GetAllBorrowingRecords()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// parseBorrowingStatus reads the optional status query parameter, answering
+// 400 itself when the value is not recognised.
+func parseBorrowingStatus(c *gin.Context) (string, bool) {
+	status := c.Query("status")
+	if status != "" && !slices.Contains(repositories.BorrowingStatuses, status) {
+		respondBadRequest(c, "status must be one of "+strings.Join(repositories.BorrowingStatuses, ", "))
+		return "", false
 	}
-
-	c.JSON(http.StatusOK, borrowingRecords)
-}
\ No newline at end of file
+	return status, true
+}
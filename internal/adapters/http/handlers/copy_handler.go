@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CopyHandler struct {
+	service services.CopyService
+}
+
+func NewCopyHandler(service services.CopyService) *CopyHandler {
+	return &CopyHandler{service: service}
+}
+
+func (h *CopyHandler) ListCopies(c *gin.Context) {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid book ID")
+		return
+	}
+
+	copies, err := h.service.ListCopies(uint(bookID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"copies": copies})
+}
+
+func (h *CopyHandler) AddCopy(c *gin.Context) {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid book ID")
+		return
+	}
+
+	var body struct {
+		Barcode   string `json:"barcode"`
+		Status    string `json:"status"`
+		Condition string `json:"condition"`
+		Location  string `json:"location"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	bookCopy := models.Copy{
+		Barcode:   body.Barcode,
+		Status:    body.Status,
+		Condition: body.Condition,
+		Location:  body.Location,
+	}
+	if err := h.service.AddCopy(uint(bookID), &bookCopy, actorFrom(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bookCopy)
+}
+
+func (h *CopyHandler) UpdateCopy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid copy ID")
+		return
+	}
+
+	var body struct {
+		Status    *string `json:"status"`
+		Condition *string `json:"condition"`
+		Location  *string `json:"location"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	bookCopy, err := h.service.UpdateCopy(uint(id), services.CopyChange{
+		Status:    body.Status,
+		Condition: body.Condition,
+		Location:  body.Location,
+	}, actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bookCopy)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"hex/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Report request body fields by their JSON names rather than Go names.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// errorBody is the JSON body of every error response. Code is stable for
+// clients to match on; Message is for people.
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// serviceErrors maps the services' sentinel errors to a status and code.
+var serviceErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{services.ErrBookNotFound, http.StatusNotFound, "book_not_found"},
+	{services.ErrBorrowingRecordNotFound, http.StatusNotFound, "borrowing_record_not_found"},
+	{services.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+	{services.ErrFineNotFound, http.StatusNotFound, "fine_not_found"},
+	{services.ErrCopyNotFound, http.StatusNotFound, "copy_not_found"},
+	{services.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found"},
+	{services.ErrDeliveryNotFound, http.StatusNotFound, "delivery_not_found"},
+	{services.ErrNotOwner, http.StatusForbidden, "not_owner"},
+	{services.ErrUnavailable, http.StatusConflict, "unavailable"},
+	{services.ErrAlreadyReturned, http.StatusConflict, "already_returned"},
+	{services.ErrRenewalRefused, http.StatusConflict, "renewal_refused"},
+	{services.ErrHoldRefused, http.StatusConflict, "hold_refused"},
+	{services.ErrHoldClosed, http.StatusConflict, "hold_closed"},
+	{services.ErrFineSettled, http.StatusConflict, "fine_settled"},
+	{services.ErrCopyOnLoan, http.StatusConflict, "copy_on_loan"},
+	{services.ErrDuplicateBarcode, http.StatusConflict, "duplicate_barcode"},
+	{services.ErrDuplicateISBN, http.StatusConflict, "duplicate_isbn"},
+	{services.ErrBookOnLoan, http.StatusConflict, "book_on_loan"},
+	{services.ErrBookNotDeleted, http.StatusConflict, "book_not_deleted"},
+	{services.ErrUnpaidFines, http.StatusConflict, "unpaid_fines"},
+	{services.ErrDeliveryPending, http.StatusConflict, "delivery_pending"},
+	{services.ErrVersionMismatch, http.StatusPreconditionFailed, "precondition_failed"},
+}
+
+// respondError translates an error returned by a service into a response.
+// Errors the services do not declare are answered with a bare 500 so that
+// internal details do not leak; the services have already logged them.
+func respondError(c *gin.Context, err error) {
+	var validation *services.ValidationError
+	if errors.As(err, &validation) {
+		c.JSON(http.StatusUnprocessableEntity, errorBody{
+			Code:    "validation_failed",
+			Message: validation.Message,
+			Details: gin.H{"field": validation.Field},
+		})
+		return
+	}
+
+	var violation *services.PolicyViolation
+	if errors.As(err, &violation) {
+		c.JSON(policyViolationStatus(violation), errorBody{Code: violation.Code, Message: violation.Message})
+		return
+	}
+
+	for _, known := range serviceErrors {
+		if errors.Is(err, known.err) {
+			c.JSON(known.status, errorBody{Code: known.code, Message: err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, errorBody{Code: "internal_error", Message: "internal server error"})
+}
+
+// respondBindError answers a request body that failed to bind: 422 listing
+// the failed rules when it was valid JSON, 400 when it could not be parsed.
+func respondBindError(c *gin.Context, err error) {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		details := make([]gin.H, len(invalid))
+		for i, fieldErr := range invalid {
+			details[i] = gin.H{"field": fieldErr.Field(), "rule": fieldErr.Tag()}
+		}
+		c.JSON(http.StatusUnprocessableEntity, errorBody{
+			Code:    "validation_failed",
+			Message: "request body failed validation",
+			Details: details,
+		})
+		return
+	}
+	respondBadRequest(c, err.Error())
+}
+
+// respondBadRequest answers a malformed request, such as an unparsable path
+// parameter or query string.
+func respondBadRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, errorBody{Code: "bad_request", Message: message})
+}
+
+// policyViolationStatus is 409 when the request clashes with a loan the
+// member already has, and 422 when the member is not allowed to borrow.
+func policyViolationStatus(violation *services.PolicyViolation) int {
+	if violation.Code == services.ViolationDuplicateLoan {
+		return http.StatusConflict
+	}
+	return http.StatusUnprocessableEntity
+}
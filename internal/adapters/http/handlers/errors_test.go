@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hex/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond runs respondError on a test context and decodes the response.
+func respond(t *testing.T, err error) (int, errorBody) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondError(c, err)
+
+	var body errorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q is not an error body: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+func TestRespondErrorMapsServiceErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{services.ErrBookNotFound, http.StatusNotFound, "book_not_found"},
+		{fmt.Errorf("%w: you can only return books you borrowed", services.ErrNotOwner), http.StatusForbidden, "not_owner"},
+		{fmt.Errorf("%w: reserved for members with holds", services.ErrUnavailable), http.StatusConflict, "unavailable"},
+		{&services.ValidationError{Field: "amount_cents", Message: "payment amount must be positive"}, http.StatusUnprocessableEntity, "validation_failed"},
+		{&services.PolicyViolation{Code: services.ViolationDuplicateLoan}, http.StatusConflict, services.ViolationDuplicateLoan},
+		{&services.PolicyViolation{Code: services.ViolationLoanLimit}, http.StatusUnprocessableEntity, services.ViolationLoanLimit},
+	}
+	for _, tt := range tests {
+		status, body := respond(t, tt.err)
+		if status != tt.status || body.Code != tt.code {
+			t.Errorf("respondError(%v) = %d %s, want %d %s", tt.err, status, body.Code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestRespondErrorHidesUnknownErrors(t *testing.T) {
+	status, body := respond(t, errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+	if status != http.StatusInternalServerError || body.Code != "internal_error" || body.Message != "internal server error" {
+		t.Errorf("respondError = %d %+v, want a bare 500", status, body)
+	}
+}
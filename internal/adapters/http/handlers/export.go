@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export formats.
+const (
+	exportCSV     = "csv"
+	exportNDJSON  = "ndjson"
+	exportMARCXML = "marcxml"
+)
+
+// exportContentTypes and exportExtensions describe each export format.
+var (
+	exportContentTypes = map[string]string{
+		exportCSV:     "text/csv; charset=utf-8",
+		exportNDJSON:  "application/x-ndjson",
+		exportMARCXML: "application/marcxml+xml",
+	}
+	exportExtensions = map[string]string{
+		exportCSV:     ".csv",
+		exportNDJSON:  ".ndjson",
+		exportMARCXML: ".xml",
+	}
+)
+
+// parseExportFormat reads format, which defaults to CSV, answering 400 itself
+// when it is not one of formats.
+func parseExportFormat(c *gin.Context, formats ...string) (string, bool) {
+	format := c.DefaultQuery("format", exportCSV)
+	if !slices.Contains(formats, format) {
+		respondBadRequest(c, "format must be one of "+strings.Join(formats, ", "))
+		return "", false
+	}
+	return format, true
+}
+
+// recordWriter writes an export one record at a time. Flush pushes buffered
+// records out after each batch. Close finishes the document; it is not
+// called when the export fails part way.
+type recordWriter[T any] interface {
+	Write(item T) error
+	Flush() error
+	Close() error
+}
+
+// streamExport answers with the records export produces, a batch at a time,
+// flushing after each. The status and headers are only sent with the first
+// batch, so an export that fails straight away still gets an error response.
+// One that fails later can only be cut short.
+func streamExport[T any](c *gin.Context, name, format string, newWriter func(w io.Writer) (recordWriter[T], error), export func(fn func([]T) error) error) {
+	var out recordWriter[T]
+	start := func() error {
+		if out != nil {
+			return nil
+		}
+		c.Header("Content-Type", exportContentTypes[format])
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, name, exportExtensions[format]))
+		c.Status(http.StatusOK)
+		var err error
+		out, err = newWriter(c.Writer)
+		return err
+	}
+
+	err := export(func(batch []T) error {
+		if err := start(); err != nil {
+			return err
+		}
+		for _, item := range batch {
+			if err := out.Write(item); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		if err = start(); err == nil {
+			err = out.Close()
+		}
+	}
+	if err != nil && out == nil {
+		respondError(c, err)
+	}
+}
+
+// csvWriter writes a header row, then one row per record.
+type csvWriter[T any] struct {
+	w   *csv.Writer
+	row func(item T) []string
+}
+
+func newCSVWriter[T any](w io.Writer, header []string, row func(item T) []string) (recordWriter[T], error) {
+	out := &csvWriter[T]{w: csv.NewWriter(w), row: row}
+	if err := out.w.Write(header); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (w *csvWriter[T]) Write(item T) error {
+	return w.w.Write(w.row(item))
+}
+
+func (w *csvWriter[T]) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter[T]) Close() error {
+	return w.Flush()
+}
+
+// ndjsonWriter writes each record as a JSON object on a line of its own.
+type ndjsonWriter[T any] struct {
+	enc    *json.Encoder
+	object func(item T) any
+}
+
+func newNDJSONWriter[T any](w io.Writer, object func(item T) any) recordWriter[T] {
+	return &ndjsonWriter[T]{enc: json.NewEncoder(w), object: object}
+}
+
+func (w *ndjsonWriter[T]) Write(item T) error {
+	return w.enc.Encode(w.object(item))
+}
+
+func (w *ndjsonWriter[T]) Flush() error {
+	return nil
+}
+
+func (w *ndjsonWriter[T]) Close() error {
+	return nil
+}
+
+// exportTime formats a timestamp for a CSV cell, leaving it empty when unset.
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func exportBook(id uint, title string) models.Book {
+	isbn := "9780547773742"
+	book := models.Book{
+		Title:           title,
+		Author:          "Ursula K. Le Guin",
+		Authors:         []models.Author{{Name: "Ursula K. Le Guin"}, {Name: "Charles Vess"}},
+		Genre:           "Fantasy",
+		ISBN:            &isbn,
+		Language:        "eng",
+		PageCount:       183,
+		PublicationDate: datatypes.Date(time.Date(1968, 11, 1, 0, 0, 0, 0, time.UTC)),
+		Availability:    2,
+		Version:         1,
+	}
+	book.ID = id
+	book.CreatedAt = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	book.UpdatedAt = book.CreatedAt
+	return book
+}
+
+// runExport streams the batches as books in format and returns the response.
+func runExport(format string, batches [][]models.Book, err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	newWriter := func(w io.Writer) (recordWriter[models.Book], error) {
+		switch format {
+		case exportNDJSON:
+			return newNDJSONWriter(w, func(book models.Book) any { return newBookExport(book) }), nil
+		case exportMARCXML:
+			return newMARCWriter(w)
+		default:
+			return newCSVWriter(w, bookCSVHeader, bookCSVRow)
+		}
+	}
+	streamExport(c, "books", format, newWriter, func(fn func([]models.Book) error) error {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		return err
+	})
+	return w
+}
+
+func TestStreamExportWritesCSV(t *testing.T) {
+	batches := [][]models.Book{
+		{exportBook(1, "A Wizard of Earthsea"), exportBook(2, "The Tombs of Atuan")},
+		{exportBook(3, "The Farthest Shore")},
+	}
+
+	w := runExport(exportCSV, batches, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("status %d, content type %q; want 200 text/csv", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="books.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not CSV: %v", err)
+	}
+	if len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(bookCSVHeader, ",") {
+		t.Fatalf("export has %d rows starting %v, want the header and 3 books", len(rows), rows[0])
+	}
+	if row := rows[3]; row[0] != "3" || row[1] != "The Farthest Shore" || row[3] != "Ursula K. Le Guin; Charles Vess" || row[4] != "1968-11-01" {
+		t.Errorf("last row = %v", row)
+	}
+}
+
+func TestStreamExportWritesHeaderWhenEmpty(t *testing.T) {
+	w := runExport(exportCSV, nil, nil)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != strings.Join(bookCSVHeader, ",") {
+		t.Errorf("status %d, body %q; want 200 with only the header row", w.Code, w.Body.String())
+	}
+}
+
+func TestStreamExportWritesNDJSON(t *testing.T) {
+	w := runExport(exportNDJSON, [][]models.Book{{exportBook(1, "A Wizard of Earthsea"), exportBook(2, "The Tombs of Atuan")}}, nil)
+
+	var titles []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var book bookExport
+		if err := json.Unmarshal(scanner.Bytes(), &book); err != nil {
+			t.Fatalf("line %q is not a book: %v", scanner.Text(), err)
+		}
+		titles = append(titles, book.Title)
+	}
+	if strings.Join(titles, "|") != "A Wizard of Earthsea|The Tombs of Atuan" {
+		t.Errorf("exported %v", titles)
+	}
+}
+
+func TestStreamExportReportsEarlyFailure(t *testing.T) {
+	w := runExport(exportCSV, nil, services.ErrBookNotFound)
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("status %d, Content-Disposition %q; want a plain 404", w.Code, w.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestStreamExportWritesMARCCollection(t *testing.T) {
+	w := runExport(exportMARCXML, [][]models.Book{{exportBook(1, "A Wizard of Earthsea")}, {exportBook(2, "The Tombs of Atuan")}}, nil)
+
+	var collection struct {
+		Records []marcRecord `xml:"record"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+		t.Fatalf("export is not XML: %v\n%s", err, w.Body.String())
+	}
+	if len(collection.Records) != 2 {
+		t.Errorf("collection has %d records, want 2", len(collection.Records))
+	}
+}
+
+func TestNewMARCRecord(t *testing.T) {
+	book := exportBook(7, "A Wizard of Earthsea")
+	book.DeletedAt = gorm.DeletedAt{Time: book.CreatedAt, Valid: true}
+
+	record := newMARCRecord(book)
+	if len(record.Leader) != 24 || record.Leader[5] != 'd' {
+		t.Errorf("leader %q, want 24 characters with status d", record.Leader)
+	}
+	if fixed := record.ControlFields[2].Value; len(fixed) != 40 || fixed[7:11] != "1968" || fixed[35:38] != "eng" {
+		t.Errorf("008 = %q, want 40 characters with 1968 and eng", fixed)
+	}
+
+	fields := map[string][]string{}
+	for _, field := range record.DataFields {
+		fields[field.Tag] = append(fields[field.Tag], field.Ind1+field.Subfields[0].Value)
+	}
+	want := map[string]string{
+		"020": " 9780547773742",
+		"100": "1Ursula K. Le Guin",
+		"245": "1A Wizard of Earthsea",
+		"300": " 183 pages",
+		"655": " Fantasy",
+		"700": "1Charles Vess",
+	}
+	for tag, value := range want {
+		if len(fields[tag]) != 1 || fields[tag][0] != value {
+			t.Errorf("field %s = %q, want %q", tag, fields[tag], value)
+		}
+	}
+	if _, ok := fields["250"]; ok {
+		t.Errorf("field 250 written for a book without an edition")
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hex/internal/adapters/http/middleware"
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FineHandler struct {
+	service services.FineService
+}
+
+func NewFineHandler(service services.FineService) *FineHandler {
+	return &FineHandler{service: service}
+}
+
+// GetMyFines lists the member's unpaid fines unless another status is asked
+// for, along with the total still owed.
+func (h *FineHandler) GetMyFines(c *gin.Context) {
+	status := c.DefaultQuery("status", models.FineStatusUnpaid)
+	if status == "all" {
+		status = ""
+	}
+	if status != "" && !slices.Contains(models.FineStatuses, status) {
+		respondBadRequest(c, "status must be all or one of "+strings.Join(models.FineStatuses, ", "))
+		return
+	}
+
+	principal, _ := middleware.PrincipalFrom(c)
+
+	fines, balance, err := h.service.GetMyFines(principal.UserID, status)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fines": fines, "outstanding_cents": balance})
+}
+
+func (h *FineHandler) GetAllFines(c *gin.Context) {
+	filter := repositories.FineFilter{Status: c.Query("status")}
+	if filter.Status != "" && !slices.Contains(models.FineStatuses, filter.Status) {
+		respondBadRequest(c, "status must be one of "+strings.Join(models.FineStatuses, ", "))
+		return
+	}
+	if value := c.Query("member_id"); value != "" {
+		memberID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			respondBadRequest(c, "Invalid member ID")
+			return
+		}
+		filter.MemberID = uint(memberID)
+	}
+
+	fines, err := h.service.GetAllFines(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fines": fines})
+}
+
+func (h *FineHandler) RecordPayment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid fine ID")
+		return
+	}
+
+	var body struct {
+		AmountCents int64 `json:"amount_cents" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	fine, err := h.service.RecordPayment(uint(id), body.AmountCents, actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
+
+func (h *FineHandler) WaiveFine(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid fine ID")
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	fine, err := h.service.WaiveFine(uint(id), body.Reason, actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
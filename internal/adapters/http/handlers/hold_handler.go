@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hex/internal/adapters/http/middleware"
+	"hex/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	service services.HoldService
+}
+
+func NewHoldHandler(service services.HoldService) *HoldHandler {
+	return &HoldHandler{service: service}
+}
+
+func (h *HoldHandler) PlaceHold(c *gin.Context) {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid book ID")
+		return
+	}
+
+	hold, err := h.service.PlaceHold(uint(bookID), actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) GetBookHolds(c *gin.Context) {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid book ID")
+		return
+	}
+
+	holds, err := h.service.GetBookHolds(uint(bookID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"holds": holds})
+}
+
+func (h *HoldHandler) GetMyHolds(c *gin.Context) {
+	principal, _ := middleware.PrincipalFrom(c)
+
+	holds, err := h.service.GetMyHolds(principal.UserID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"holds": holds})
+}
+
+func (h *HoldHandler) CancelHold(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid hold ID")
+		return
+	}
+
+	if err := h.service.CancelHold(uint(id), actorFrom(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hold cancelled successfully"})
+}
@@ -0,0 +1,25 @@
+package handlers
+
+// mergePatch applies a JSON Merge Patch (RFC 7396) to target. Both are
+// values decoded from JSON into interface{}. A null in the patch removes the
+// member, an object is merged member by member, and anything else replaces
+// the target outright.
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]any)
+	if !ok {
+		merged = map[string]any{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = mergePatch(merged[name], value)
+	}
+	return merged
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service services.WebhookService
+}
+
+func NewWebhookHandler(service services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// createdWebhook is a new webhook along with its secret, which is not shown
+// again.
+type createdWebhook struct {
+	models.WebhookSubscription
+	Secret string
+}
+
+// CreateWebhook subscribes a URL to event types. The secret deliveries are
+// signed with is generated unless one is given, and is only returned here.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var body struct {
+		URL         string   `json:"url" binding:"required"`
+		Description string   `json:"description"`
+		EventTypes  []string `json:"event_types" binding:"required"`
+		Secret      string   `json:"secret"`
+		Active      *bool    `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	subscription := models.WebhookSubscription{
+		URL:         body.URL,
+		Description: body.Description,
+		Secret:      body.Secret,
+		Active:      body.Active == nil || *body.Active,
+	}
+	if err := h.service.CreateWebhook(&subscription, body.EventTypes, actorFrom(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdWebhook{WebhookSubscription: subscription, Secret: subscription.Secret})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.service.ListWebhooks()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": subscriptions})
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	subscription, err := h.service.GetWebhook(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// UpdateWebhook changes the fields given. Setting secret replaces the one
+// deliveries are signed with; active false pauses the webhook.
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	var body struct {
+		URL         *string   `json:"url"`
+		Description *string   `json:"description"`
+		EventTypes  *[]string `json:"event_types"`
+		Secret      *string   `json:"secret"`
+		Active      *bool     `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	subscription, err := h.service.UpdateWebhook(uint(id), services.WebhookChange{
+		URL:         body.URL,
+		Description: body.Description,
+		EventTypes:  body.EventTypes,
+		Secret:      body.Secret,
+		Active:      body.Active,
+	}, actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.service.DeleteWebhook(uint(id), actorFrom(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// ListDeliveries pages through a webhook's delivery log, newest first,
+// optionally narrowed to one status.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return
+	}
+	status := c.Query("status")
+	if status != "" && !slices.Contains(models.WebhookDeliveryStatuses, status) {
+		respondBadRequest(c, fmt.Sprintf("status must be one of %s", strings.Join(models.WebhookDeliveryStatuses, ", ")))
+		return
+	}
+
+	query := repositories.WebhookDeliveryQuery{
+		Filter:   repositories.WebhookDeliveryFilter{Status: status},
+		Page:     page,
+		PageSize: pageSize,
+	}
+	deliveries, total, err := h.service.ListDeliveries(uint(id), query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"meta":       newPageMeta(page, pageSize, total),
+	})
+}
+
+// RetryDelivery sends a dead or cancelled delivery again.
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.service.RetryDelivery(uint(id), uint(deliveryID), actorFrom(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
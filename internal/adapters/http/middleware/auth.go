@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"hex/internal/application/auth"
+	"hex/internal/application/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// invalidCredentials is all a rejected client is told, so that the reason a
+// token failed verification never leaks into the response.
+const invalidCredentials = "invalid or missing credentials"
+
+// Authenticate verifies the Authorization header once per request and stores
+// the resulting principal in the context. Missing or invalid tokens are
+// rejected with 401; why a token was rejected is only logged.
+func Authenticate(authService auth.AuthService, logger logging.Logger) gin.HandlerFunc {
+	reject := func(c *gin.Context, reason string) {
+		logger.Log("WARN", "Authentication failed: "+reason, logging.F("request_id", RequestIDFrom(c)), logging.F("path", c.Request.URL.Path))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "unauthorized", "message": invalidCredentials})
+	}
+
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if token == "" {
+			reject(c, "missing authorization token")
+			return
+		}
+
+		userID, role, err := authService.Authenticate(token)
+		if err != nil {
+			reject(c, err.Error())
+			return
+		}
+
+		principal, err := auth.NewPrincipal(userID, role)
+		if err != nil {
+			reject(c, err.Error())
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireRole lets the request through only when the authenticated principal
+// holds one of roles. It answers 401 when Authenticate did not run first and
+// 403 when the role does not match.
+func RequireRole(roles ...auth.Role) gin.HandlerFunc {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	message := "forbidden: requires role " + strings.Join(names, " or ")
+
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "unauthorized", "message": "missing authorization token"})
+			return
+		}
+		if !principal.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "forbidden", "message": message})
+			return
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the principal stored by Authenticate.
+func PrincipalFrom(c *gin.Context) (auth.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	principal, ok := value.(auth.Principal)
+	return principal, ok
+}
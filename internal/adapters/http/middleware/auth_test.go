@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hex/internal/application/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failingAuthService struct{ err error }
+
+func (s failingAuthService) Authenticate(string) (string, string, error) {
+	return "", "", s.err
+}
+
+type recordingLogger struct{ messages []string }
+
+func (l *recordingLogger) Log(_ string, message string, _ ...logging.Field) {
+	l.messages = append(l.messages, message)
+}
+
+func TestAuthenticateHidesRejectionReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reason := "token signature is invalid: no key for kid 7"
+
+	for name, token := range map[string]string{"missing": "", "invalid": "Bearer forged"} {
+		t.Run(name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			r := gin.New()
+			r.GET("/", Authenticate(failingAuthService{err: errors.New(reason)}, logger), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if token != "" {
+				req.Header.Set("Authorization", token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var body struct{ Code, Message string }
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body.String(), err)
+			}
+			if w.Code != http.StatusUnauthorized || body.Message != invalidCredentials {
+				t.Errorf("got %d %q, want %d %q", w.Code, body.Message, http.StatusUnauthorized, invalidCredentials)
+			}
+			if len(logger.messages) != 1 {
+				t.Fatalf("logged %q, want one entry", logger.messages)
+			}
+			if token != "" && !strings.Contains(logger.messages[0], reason) {
+				t.Errorf("logged %q, want the reason %q", logger.messages[0], reason)
+			}
+		})
+	}
+}
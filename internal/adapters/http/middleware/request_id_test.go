@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDKeepsValidClientIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header string
+		kept   bool
+	}{
+		{"", false},
+		{"proxy-42", true},
+		{"has space", false},
+		{"café", false},
+		{strings.Repeat("a", maxRequestIDLength), true},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		var seen string
+		r := gin.New()
+		r.GET("/", RequestID(), func(c *gin.Context) {
+			seen = RequestIDFrom(c)
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, tt.header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		echoed := w.Header().Get(RequestIDHeader)
+		if echoed != seen || seen == "" {
+			t.Errorf("%q: handler saw %q, response carries %q; want the same non-empty ID", tt.header, seen, echoed)
+		}
+		if kept := seen == tt.header; kept != tt.kept {
+			t.Errorf("%q: kept = %v, want %v", tt.header, kept, tt.kept)
+		}
+	}
+}
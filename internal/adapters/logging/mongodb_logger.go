@@ -2,44 +2,235 @@ package logging
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"hex/internal/application/logging"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// OverflowPolicy decides what Log does when the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards the entry and counts it as dropped.
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowBlock waits until the background writer frees a slot.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// ErrInvalidOverflow is returned for an overflow policy that is neither
+// OverflowDrop nor OverflowBlock.
+var ErrInvalidOverflow = errors.New("invalid log overflow policy")
+
+// MongoDBOptions tunes the queue that sits between Log and MongoDB.
+type MongoDBOptions struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	Overflow      OverflowPolicy
+}
+
+// DefaultMongoDBOptions returns the settings used when none are configured.
+func DefaultMongoDBOptions() MongoDBOptions {
+	return MongoDBOptions{
+		QueueSize:     1024,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		Overflow:      OverflowDrop,
+	}
+}
+
+// MongoDBLogger ships entries to MongoDB without blocking the request path.
+// Log only enqueues; a background goroutine writes batches with InsertMany
+// once BatchSize entries are waiting or FlushInterval has passed. Close
+// flushes whatever is still queued.
 type MongoDBLogger struct {
 	client     *mongo.Client
 	collection *mongo.Collection
+	opts       MongoDBOptions
+
+	// mu guards closed. Log holds it for reading while it enqueues, so once
+	// Close has taken it to set closed no entry can slip into the queue
+	// after the writer has drained it.
+	mu      sync.RWMutex
+	closed  bool
+	entries chan interface{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
 }
 
-func NewMongoDBLogger(uri, dbName, collectionName string) *MongoDBLogger {
+var _ logging.Logger = (*MongoDBLogger)(nil)
+
+// NewMongoDBLogger connects to MongoDB and checks that the server answers.
+// It returns an error instead of exiting so callers can fall back to another
+// logger when MongoDB is unreachable. Zero options take their defaults; an
+// unknown Overflow is refused with ErrInvalidOverflow.
+func NewMongoDBLogger(uri, dbName, collectionName string, opts MongoDBOptions) (*MongoDBLogger, error) {
+	defaults := DefaultMongoDBOptions()
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+	switch opts.Overflow {
+	case "":
+		opts.Overflow = defaults.Overflow
+	case OverflowDrop, OverflowBlock:
+	default:
+		return nil, fmt.Errorf("%w %q, expected %q or %q", ErrInvalidOverflow, opts.Overflow, OverflowDrop, OverflowBlock)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
 	clientOptions := options.Client().ApplyURI(uri)
-	client, err := mongo.Connect(context.TODO(), clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to reach MongoDB: %w", err)
 	}
 
-	collection := client.Database(dbName).Collection(collectionName)
-	return &MongoDBLogger{
+	l := &MongoDBLogger{
 		client:     client,
-		collection: collection,
+		collection: client.Database(dbName).Collection(collectionName),
+		opts:       opts,
+		entries:    make(chan interface{}, opts.QueueSize),
+		done:       make(chan struct{}),
 	}
+	l.wg.Add(1)
+	go l.run()
+	return l, nil
 }
 
-func (l *MongoDBLogger) Log(level string, message string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+func (l *MongoDBLogger) Log(level string, message string, fields ...logging.Field) {
 	logEntry := map[string]interface{}{
 		"level":     level,
 		"message":   message,
 		"timestamp": time.Now(),
 	}
+	if len(fields) > 0 {
+		entryFields := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			entryFields[field.Key] = field.Value
+		}
+		logEntry["fields"] = entryFields
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		l.dropped.Add(1)
+		return
+	}
+
+	// The writer keeps draining until Close, so blocking here cannot hold
+	// Close up for longer than it takes to free a slot
+	if l.opts.Overflow == OverflowBlock {
+		l.entries <- logEntry
+		return
+	}
+
+	select {
+	case l.entries <- logEntry:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Dropped reports how many entries never reached MongoDB, either because the
+// queue was full, the logger was closed, or the batch insert failed.
+func (l *MongoDBLogger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Close stops accepting entries, flushes the queue and disconnects. Entries
+// logged after Close are counted as dropped.
+func (l *MongoDBLogger) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.done)
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+
+	if dropped := l.Dropped(); dropped > 0 {
+		log.Printf("MongoDB logger dropped %d entries", dropped)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return l.client.Disconnect(ctx)
+}
+
+func (l *MongoDBLogger) run() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, l.opts.BatchSize)
+	add := func(entry interface{}) {
+		batch = append(batch, entry)
+		if len(batch) >= l.opts.BatchSize {
+			l.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case entry := <-l.entries:
+			add(entry)
+		case <-ticker.C:
+			l.flush(batch)
+			batch = batch[:0]
+		case <-l.done:
+			// Drain what was queued before Close and write it out
+			for {
+				select {
+				case entry := <-l.entries:
+					add(entry)
+				default:
+					l.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *MongoDBLogger) flush(batch []interface{}) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	_, err := l.collection.InsertOne(ctx, logEntry)
+	_, err := l.collection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
 	if err != nil {
+		inserted := 0
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+			inserted = len(batch) - len(bulkErr.WriteErrors)
+		}
+		l.dropped.Add(uint64(len(batch) - inserted))
 		log.Printf("Failed to log to MongoDB: %v", err)
 	}
 }
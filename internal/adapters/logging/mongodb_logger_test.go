@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewMongoDBLoggerRefusesUnknownOverflow(t *testing.T) {
+	opts := DefaultMongoDBOptions()
+	opts.Overflow = "spill"
+	// The options are checked before connecting, so no server is needed
+	if _, err := NewMongoDBLogger("mongodb://127.0.0.1:1", "hex", "logs", opts); !errors.Is(err, ErrInvalidOverflow) {
+		t.Fatalf("NewMongoDBLogger: err = %v, want %v", err, ErrInvalidOverflow)
+	}
+}
+
+func TestMongoDBLoggerDropsAfterClose(t *testing.T) {
+	for _, overflow := range []OverflowPolicy{OverflowDrop, OverflowBlock} {
+		l := &MongoDBLogger{
+			opts:    MongoDBOptions{Overflow: overflow},
+			entries: make(chan interface{}, 1),
+			closed:  true,
+		}
+		l.Log("INFO", "too late")
+		l.Log("INFO", "still too late")
+		if len(l.entries) != 0 || l.Dropped() != 2 {
+			t.Errorf("%s: %d queued, %d dropped after close; want 0, 2", overflow, len(l.entries), l.Dropped())
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package logging
+
+import "hex/internal/application/logging"
+
+// NopLogger discards every entry.
+type NopLogger struct{}
+
+var _ logging.Logger = NopLogger{}
+
+func NewNopLogger() NopLogger {
+	return NopLogger{}
+}
+
+func (NopLogger) Log(string, string, ...logging.Field) {}
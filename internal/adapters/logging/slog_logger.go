@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"hex/internal/application/logging"
+)
+
+// SlogLogger writes one JSON object per entry through log/slog.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+var _ logging.Logger = (*SlogLogger)(nil)
+
+func NewSlogLogger(w io.Writer) *SlogLogger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return &SlogLogger{logger: slog.New(handler)}
+}
+
+func (l *SlogLogger) Log(level string, message string, fields ...logging.Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	l.logger.LogAttrs(context.Background(), slogLevel(level), message, attrs...)
+}
+
+func slogLevel(level string) slog.Level {
+	switch level {
+	case logging.LevelDebug:
+		return slog.LevelDebug
+	case logging.LevelWarn:
+		return slog.LevelWarn
+	case logging.LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
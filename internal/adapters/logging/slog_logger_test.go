@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"hex/internal/application/logging"
+)
+
+func TestSlogLoggerWritesJSONEntries(t *testing.T) {
+	var out bytes.Buffer
+	NewSlogLogger(&out).Log(logging.LevelWarn, "Book borrowed", logging.F("book_id", 7), logging.F("member", "ged"))
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("output %q is not one JSON object: %v", out.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "Book borrowed" {
+		t.Errorf("level, msg = %v, %v; want WARN, Book borrowed", entry["level"], entry["msg"])
+	}
+	if entry["book_id"] != float64(7) || entry["member"] != "ged" {
+		t.Errorf("fields = %v, %v; want 7, ged", entry["book_id"], entry["member"])
+	}
+}
+
+func TestSlogLoggerMapsLevels(t *testing.T) {
+	levels := map[string]string{
+		logging.LevelDebug: "DEBUG",
+		logging.LevelInfo:  "INFO",
+		logging.LevelWarn:  "WARN",
+		logging.LevelError: "ERROR",
+		"NOTICE":           "INFO",
+	}
+	for level, want := range levels {
+		var out bytes.Buffer
+		NewSlogLogger(&out).Log(level, "message")
+		var entry map[string]any
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: output %q is not JSON: %v", level, out.String(), err)
+		}
+		if entry["level"] != want {
+			t.Errorf("Log(%q) wrote level %v, want %s", level, entry["level"], want)
+		}
+	}
+}
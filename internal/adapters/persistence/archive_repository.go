@@ -0,0 +1,22 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type ArchiveRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.ArchiveRepository = (*ArchiveRepository)(nil)
+
+func NewArchiveRepository(db *gorm.DB) *ArchiveRepository {
+	return &ArchiveRepository{DB: db}
+}
+
+func (r *ArchiveRepository) Create(archive *models.BorrowingArchive) error {
+	return r.DB.Create(archive).Error
+}
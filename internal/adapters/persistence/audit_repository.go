@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	return r.DB.Create(entry).Error
+}
+
+func (r *AuditRepository) List(query repositories.AuditQuery) ([]models.AuditEntry, int64, error) {
+	filter := auditFilterScope(query.Filter)
+
+	var total int64
+	if err := r.DB.Model(&models.AuditEntry{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	db := r.DB.Scopes(filter).Order("id DESC")
+	if query.PageSize > 0 {
+		db = db.Offset(query.Offset()).Limit(query.PageSize)
+	}
+
+	var entries []models.AuditEntry
+	err := db.Find(&entries).Error
+	return entries, total, err
+}
+
+func auditFilterScope(filter repositories.AuditFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.EntityType != "" {
+			db = db.Where("entity_type = ?", filter.EntityType)
+		}
+		if filter.EntityID != 0 {
+			db = db.Where("entity_id = ?", filter.EntityID)
+		}
+		if filter.ActorID != 0 {
+			db = db.Where("actor_id = ?", filter.ActorID)
+		}
+		if filter.Action != "" {
+			db = db.Where("action = ?", filter.Action)
+		}
+		if filter.From != nil {
+			db = db.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("created_at < ?", *filter.To)
+		}
+		return db
+	}
+}
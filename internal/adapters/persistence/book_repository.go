@@ -1,40 +1,155 @@
 package persistence
 
 import (
+	"strings"
+
+	"hex/internal/application/repositories"
 	"hex/pkg/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookRepository struct {
 	DB *gorm.DB
 }
 
+var _ repositories.BookRepository = (*BookRepository)(nil)
+
 func NewBookRepository(db *gorm.DB) *BookRepository {
 	return &BookRepository{DB: db}
 }
 
 func (r *BookRepository) Create(book *models.Book) error {
-	return r.DB.Create(book).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(book).Error; err != nil {
+			return translateError(err)
+		}
+		return saveContributors(tx, book)
+	})
 }
 
 func (r *BookRepository) GetAll() ([]models.Book, error) {
 	var books []models.Book
-	err := r.DB.Find(&books).Error
+	err := r.DB.Scopes(withAvailability, withContributors).Find(&books).Error
 	return books, err
 }
 
 func (r *BookRepository) Update(book *models.Book) error {
-	return r.DB.Save(book).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(book).Error; err != nil {
+			return translateError(err)
+		}
+		return saveContributors(tx, book)
+	})
+}
+
+// saveContributors finds or creates the book's authors and subjects by name
+// and makes them the book's only ones.
+func saveContributors(tx *gorm.DB, book *models.Book) error {
+	for i := range book.Authors {
+		author := &book.Authors[i]
+		if err := tx.Where("name = ?", author.Name).Attrs(models.Author{Name: author.Name}).FirstOrCreate(author).Error; err != nil {
+			return err
+		}
+	}
+	for i := range book.Subjects {
+		subject := &book.Subjects[i]
+		if err := tx.Where("name = ?", subject.Name).Attrs(models.Subject{Name: subject.Name}).FirstOrCreate(subject).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(book).Association("Authors").Replace(book.Authors); err != nil {
+		return err
+	}
+	return tx.Model(book).Association("Subjects").Replace(book.Subjects)
 }
 
-func (r *BookRepository) Delete(id string) error {
+func (r *BookRepository) Delete(id uint) error {
 	return r.DB.Delete(&models.Book{}, id).Error
 }
 
+func (r *BookRepository) Restore(id uint) error {
+	return r.DB.Unscoped().Model(&models.Book{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func (r *BookRepository) Purge(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		records := tx.Unscoped().Model(&models.BorrowingRecord{}).Select("id").Where("book_id = ?", id)
+		steps := []*gorm.DB{
+			tx.Unscoped().Where("borrowing_record_id IN (?)", records).Delete(&models.Fine{}),
+			tx.Unscoped().Where("book_id = ?", id).Delete(&models.BorrowingRecord{}),
+			tx.Unscoped().Where("book_id = ?", id).Delete(&models.Hold{}),
+			tx.Unscoped().Where("book_id = ?", id).Delete(&models.Copy{}),
+			tx.Exec("DELETE FROM book_authors WHERE book_id = ?", id),
+			tx.Exec("DELETE FROM book_subjects WHERE book_id = ?", id),
+			tx.Unscoped().Delete(&models.Book{}, id),
+		}
+		for _, step := range steps {
+			if step.Error != nil {
+				return step.Error
+			}
+		}
+		return nil
+	})
+}
+
 func (r *BookRepository) GetByID(id uint) (*models.Book, error) {
 	var book models.Book
-	err := r.DB.First(&book, id).Error
+	err := r.DB.Scopes(withAvailability, withContributors).First(&book, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+// GetByIDForUpdate loads a book and locks its row until the surrounding
+// transaction ends. Outside a transaction the lock is released immediately.
+func (r *BookRepository) GetByIDForUpdate(id uint) (*models.Book, error) {
+	var book models.Book
+	err := r.DB.Scopes(withAvailability, withContributors).Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (r *BookRepository) GetByIDIncludingDeleted(id uint) (*models.Book, error) {
+	var book models.Book
+	err := r.DB.Unscoped().Scopes(withAvailability, withContributors).First(&book, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (r *BookRepository) GetByISBN(isbn string) (*models.Book, error) {
+	var book models.Book
+	err := r.DB.Unscoped().Where("isbn = ?", isbn).First(&book).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (r *BookRepository) GetByTitleAndAuthor(title, author string) (*models.Book, error) {
+	var book models.Book
+	err := r.DB.Scopes(withAvailability, withContributors).
+		Where("LOWER(title) = LOWER(?) AND LOWER(author) = LOWER(?)", title, author).
+		Order("id").First(&book).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -43,3 +158,171 @@ func (r *BookRepository) GetByID(id uint) (*models.Book, error) {
 	}
 	return &book, nil
 }
+
+// availableCopies counts the copies of the book in the current row that are
+// on the shelf. It fills Book.Availability, which has no column of its own.
+const availableCopies = "(SELECT COUNT(*) FROM copies WHERE copies.book_id = books.id" +
+	" AND copies.status = 'available' AND copies.deleted_at IS NULL)"
+
+// withAvailability selects books along with their available copy count.
+func withAvailability(db *gorm.DB) *gorm.DB {
+	return db.Select("books.*, " + availableCopies + " AS availability")
+}
+
+// withContributors loads the authors and subjects of each book.
+func withContributors(db *gorm.DB) *gorm.DB {
+	return db.Preload("Authors").Preload("Subjects")
+}
+
+var bookSortColumns = map[string]string{
+	repositories.BookSortTitle:           "title",
+	repositories.BookSortAuthor:          "author",
+	repositories.BookSortGenre:           "genre",
+	repositories.BookSortPublicationDate: "publication_date",
+	repositories.BookSortAvailability:    "availability",
+	repositories.BookSortCreatedAt:       "created_at",
+}
+
+func (r *BookRepository) List(query repositories.BookQuery) ([]models.Book, int64, error) {
+	filter := bookFilterScope(query.Filter)
+
+	var total int64
+	if err := r.DB.Model(&models.Book{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	db := r.DB.Scopes(filter, withAvailability, withContributors)
+	for _, sort := range query.Sort {
+		column, ok := bookSortColumns[sort.Field]
+		if !ok {
+			continue
+		}
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: sort.Desc})
+	}
+	db = db.Order("id")
+	if query.PageSize > 0 {
+		db = db.Offset(query.Offset()).Limit(query.PageSize)
+	}
+
+	var books []models.Book
+	err := db.Find(&books).Error
+	return books, total, err
+}
+
+func (r *BookRepository) EachBatch(filter repositories.BookFilter, size int, fn func(books []models.Book) error) error {
+	var books []models.Book
+	return r.DB.Scopes(bookFilterScope(filter), withAvailability, withContributors).
+		FindInBatches(&books, size, func(*gorm.DB, int) error {
+			return fn(books)
+		}).Error
+}
+
+func bookFilterScope(filter repositories.BookFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.IncludeDeleted {
+			db = db.Unscoped()
+		}
+		if filter.Genre != "" {
+			db = db.Where("LOWER(genre) = LOWER(?)", filter.Genre)
+		}
+		if filter.Author != "" {
+			db = db.Where("LOWER(author) LIKE LOWER(?)", "%"+escapeLike(filter.Author)+"%")
+		}
+		if filter.Available != nil {
+			if *filter.Available {
+				db = db.Where(availableCopies + " > 0")
+			} else {
+				db = db.Where(availableCopies + " = 0")
+			}
+		}
+		if filter.PublishedFrom != nil {
+			db = db.Where("publication_date >= ?", *filter.PublishedFrom)
+		}
+		if filter.PublishedTo != nil {
+			db = db.Where("publication_date <= ?", *filter.PublishedTo)
+		}
+		if filter.ISBN != "" {
+			db = db.Where("isbn = ?", filter.ISBN)
+		}
+		if filter.Publisher != "" {
+			db = db.Where("LOWER(publisher) LIKE LOWER(?)", "%"+escapeLike(filter.Publisher)+"%")
+		}
+		if filter.Description != "" {
+			db = db.Where("LOWER(description) LIKE LOWER(?)", "%"+escapeLike(filter.Description)+"%")
+		}
+		if filter.Edition != "" {
+			db = db.Where("LOWER(edition) = LOWER(?)", filter.Edition)
+		}
+		if filter.Language != "" {
+			db = db.Where("LOWER(language) = LOWER(?)", filter.Language)
+		}
+		if filter.Subject != "" {
+			db = db.Where("EXISTS (SELECT 1 FROM book_subjects JOIN subjects ON subjects.id = book_subjects.subject_id"+
+				" WHERE book_subjects.book_id = books.id AND LOWER(subjects.name) = LOWER(?))", filter.Subject)
+		}
+		if filter.MinPages != nil {
+			db = db.Where("page_count >= ?", *filter.MinPages)
+		}
+		if filter.MaxPages != nil {
+			db = db.Where("page_count <= ?", *filter.MaxPages)
+		}
+		if filter.HasCover != nil {
+			if *filter.HasCover {
+				db = db.Where("COALESCE(cover_url, '') <> ''")
+			} else {
+				db = db.Where("COALESCE(cover_url, '') = ''")
+			}
+		}
+		return db
+	}
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Search ranks books with the FULLTEXT index created by Migrate, using
+// natural language mode so MySQL computes the relevance score.
+func (r *BookRepository) Search(text string, limit int) ([]repositories.BookMatch, error) {
+	const match = "MATCH(title, author, genre) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	var rows []struct {
+		models.Book
+		Score float64
+	}
+	err := r.DB.Model(&models.Book{}).
+		Select("books.*, "+availableCopies+" AS availability, "+match+" AS score", text).
+		Where(match, text).
+		Order("score DESC").
+		Order("id").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan cannot preload, so load authors and subjects separately
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var loaded []models.Book
+	if len(ids) > 0 {
+		if err := r.DB.Select("id").Scopes(withContributors).Find(&loaded, ids).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]models.Book, len(loaded))
+	for _, book := range loaded {
+		byID[book.ID] = book
+	}
+
+	matches := make([]repositories.BookMatch, len(rows))
+	for i, row := range rows {
+		row.Authors = byID[row.ID].Authors
+		row.Subjects = byID[row.ID].Subjects
+		matches[i] = repositories.BookMatch{Book: row.Book, Score: row.Score}
+	}
+	return matches, nil
+}
@@ -1,15 +1,19 @@
 package persistence
 
 import (
+	"hex/internal/application/repositories"
 	"hex/pkg/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BorrowingRepository struct {
 	DB *gorm.DB
 }
 
+var _ repositories.BorrowingRepository = (*BorrowingRepository)(nil)
+
 func NewBorrowingRepository(db *gorm.DB) *BorrowingRepository {
 	return &BorrowingRepository{DB: db}
 }
@@ -20,7 +24,7 @@ func (r *BorrowingRepository) Create(borrowingRecord *models.BorrowingRecord) er
 
 func (r *BorrowingRepository) GetByID(id uint) (*models.BorrowingRecord, error) {
 	var borrowingRecord models.BorrowingRecord
-	err := r.DB.Preload("Book").First(&borrowingRecord, id).Error
+	err := r.DB.Preload("Book", withAvailability).Preload("Copy").First(&borrowingRecord, id).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -30,18 +34,61 @@ func (r *BorrowingRepository) GetByID(id uint) (*models.BorrowingRecord, error)
 	return &borrowingRecord, nil
 }
 
-func (r *BorrowingRepository) GetByMemberID(memberID uint) ([]models.BorrowingRecord, error) {
+// GetByIDForUpdate loads a borrowing record and locks its row until the
+// surrounding transaction ends, so the same loan cannot be returned twice.
+func (r *BorrowingRepository) GetByIDForUpdate(id uint) (*models.BorrowingRecord, error) {
+	var borrowingRecord models.BorrowingRecord
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&borrowingRecord, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &borrowingRecord, nil
+}
+
+func (r *BorrowingRepository) List(filter repositories.BorrowingFilter) ([]models.BorrowingRecord, error) {
 	var borrowingRecords []models.BorrowingRecord
-	err := r.DB.Where("member_id = ?", memberID).Preload("Book").Find(&borrowingRecords).Error
+	err := r.DB.Scopes(borrowingFilterScope(filter)).Preload("Book", withAvailability).Preload("Copy").Order("id").Find(&borrowingRecords).Error
 	return borrowingRecords, err
 }
 
-func (r *BorrowingRepository) GetAll() ([]models.BorrowingRecord, error) {
+func (r *BorrowingRepository) EachBatch(filter repositories.BorrowingFilter, size int, fn func(records []models.BorrowingRecord) error) error {
 	var borrowingRecords []models.BorrowingRecord
-	err := r.DB.Preload("Book").Find(&borrowingRecords).Error
-	return borrowingRecords, err
+	return r.DB.Scopes(borrowingFilterScope(filter)).Preload("Book", withAvailability).Preload("Copy").
+		FindInBatches(&borrowingRecords, size, func(*gorm.DB, int) error {
+			return fn(borrowingRecords)
+		}).Error
 }
 
 func (r *BorrowingRepository) Update(borrowingRecord *models.BorrowingRecord) error {
-	return r.DB.Save(borrowingRecord).Error
+	return r.DB.Omit(clause.Associations).Save(borrowingRecord).Error
+}
+
+func borrowingFilterScope(filter repositories.BorrowingFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.MemberID != 0 {
+			db = db.Where("member_id = ?", filter.MemberID)
+		}
+		if filter.BookID != 0 {
+			db = db.Where("book_id = ?", filter.BookID)
+		}
+		switch filter.Status {
+		case repositories.BorrowingStatusActive:
+			db = db.Where("return_date IS NULL")
+		case repositories.BorrowingStatusOverdue:
+			db = db.Where("return_date IS NULL AND due_date < ?", filter.Now)
+		case repositories.BorrowingStatusReturned:
+			db = db.Where("return_date IS NOT NULL")
+		}
+		if filter.Overdue != nil {
+			if *filter.Overdue {
+				db = db.Where("return_date IS NULL AND due_date < ?", filter.Now)
+			} else {
+				db = db.Where("return_date IS NOT NULL OR due_date IS NULL OR due_date >= ?", filter.Now)
+			}
+		}
+		return db
+	}
 }
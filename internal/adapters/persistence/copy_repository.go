@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CopyRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.CopyRepository = (*CopyRepository)(nil)
+
+func NewCopyRepository(db *gorm.DB) *CopyRepository {
+	return &CopyRepository{DB: db}
+}
+
+func (r *CopyRepository) Create(bookCopy *models.Copy) error {
+	return translateError(r.DB.Create(bookCopy).Error)
+}
+
+func (r *CopyRepository) GetByID(id uint) (*models.Copy, error) {
+	var bookCopy models.Copy
+	err := r.DB.First(&bookCopy, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *CopyRepository) GetByIDForUpdate(id uint) (*models.Copy, error) {
+	var bookCopy models.Copy
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *CopyRepository) List(filter repositories.CopyFilter) ([]models.Copy, error) {
+	db := r.DB
+	if filter.BookID != 0 {
+		db = db.Where("book_id = ?", filter.BookID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Barcode != "" {
+		db = db.Where("LOWER(barcode) = LOWER(?)", filter.Barcode)
+	}
+
+	var copies []models.Copy
+	err := db.Order("id").Find(&copies).Error
+	return copies, err
+}
+
+func (r *CopyRepository) Update(bookCopy *models.Copy) error {
+	return translateError(r.DB.Save(bookCopy).Error)
+}
+
+func (r *CopyRepository) NextAvailableForUpdate(bookID uint) (*models.Copy, error) {
+	var bookCopy models.Copy
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookID, models.CopyStatusAvailable).
+		Order("id").
+		First(&bookCopy).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+func (r *CopyRepository) CountForBook(bookID uint) (int64, error) {
+	var count int64
+	err := r.DB.Unscoped().Model(&models.Copy{}).Where("book_id = ?", bookID).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,23 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"hex/internal/application/repositories"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for a unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// translateError reports a unique key violation as repositories.ErrDuplicate
+// and passes other errors through.
+func translateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return fmt.Errorf("%w: %s", repositories.ErrDuplicate, mysqlErr.Message)
+	}
+	return err
+}
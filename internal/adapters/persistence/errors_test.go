@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"hex/internal/application/repositories"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTranslateError(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'B1-0001' for key 'idx_copies_barcode'"}
+	if err := translateError(fmt.Errorf("insert: %w", duplicate)); !errors.Is(err, repositories.ErrDuplicate) {
+		t.Errorf("translateError(1062) = %v, want ErrDuplicate", err)
+	}
+
+	for _, err := range []error{nil, errors.New("connection reset"), &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}} {
+		if got := translateError(err); got != err {
+			t.Errorf("translateError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
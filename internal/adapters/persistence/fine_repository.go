@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FineRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.FineRepository = (*FineRepository)(nil)
+
+func NewFineRepository(db *gorm.DB) *FineRepository {
+	return &FineRepository{DB: db}
+}
+
+func (r *FineRepository) Create(fine *models.Fine) error {
+	return r.DB.Omit(clause.Associations).Create(fine).Error
+}
+
+func (r *FineRepository) GetByID(id uint) (*models.Fine, error) {
+	var fine models.Fine
+	err := r.DB.Preload("BorrowingRecord.Book", withAvailability).First(&fine, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fine, nil
+}
+
+func (r *FineRepository) GetByIDForUpdate(id uint) (*models.Fine, error) {
+	var fine models.Fine
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fine, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fine, nil
+}
+
+func (r *FineRepository) List(filter repositories.FineFilter) ([]models.Fine, error) {
+	db := r.DB
+	if filter.MemberID != 0 {
+		db = db.Where("member_id = ?", filter.MemberID)
+	}
+	if filter.BorrowingRecordID != 0 {
+		db = db.Where("borrowing_record_id = ?", filter.BorrowingRecordID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+
+	var fines []models.Fine
+	err := db.Preload("BorrowingRecord.Book", withAvailability).Order("id").Find(&fines).Error
+	return fines, err
+}
+
+func (r *FineRepository) Update(fine *models.Fine) error {
+	return r.DB.Omit(clause.Associations).Save(fine).Error
+}
+
+func (r *FineRepository) OutstandingBalance(memberID uint) (int64, error) {
+	var balance int64
+	err := r.DB.Model(&models.Fine{}).
+		Where("member_id = ? AND status = ?", memberID, models.FineStatusUnpaid).
+		Select("COALESCE(SUM(amount_cents - paid_cents), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
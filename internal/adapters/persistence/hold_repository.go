@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.HoldRepository = (*HoldRepository)(nil)
+
+func NewHoldRepository(db *gorm.DB) *HoldRepository {
+	return &HoldRepository{DB: db}
+}
+
+func (r *HoldRepository) Create(hold *models.Hold) error {
+	return r.DB.Omit(clause.Associations).Create(hold).Error
+}
+
+func (r *HoldRepository) GetByID(id uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.DB.Preload("Book", withAvailability).First(&hold, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *HoldRepository) GetByIDForUpdate(id uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *HoldRepository) List(filter repositories.HoldFilter) ([]models.Hold, error) {
+	db := r.DB
+	if filter.BookID != 0 {
+		db = db.Where("book_id = ?", filter.BookID)
+	}
+	if filter.MemberID != 0 {
+		db = db.Where("member_id = ?", filter.MemberID)
+	}
+	if len(filter.Statuses) > 0 {
+		db = db.Where("status IN ?", filter.Statuses)
+	}
+
+	var holds []models.Hold
+	err := db.Preload("Book", withAvailability).Order("id").Find(&holds).Error
+	return holds, err
+}
+
+func (r *HoldRepository) Update(hold *models.Hold) error {
+	return r.DB.Omit(clause.Associations).Save(hold).Error
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"hex/internal/application/repositories"
+)
+
+func TestGetByIDForUpdateLocksRow(t *testing.T) {
+	reads := map[string]func(tx repositories.Tx) error{
+		"book": func(tx repositories.Tx) error {
+			_, err := tx.Books.GetByIDForUpdate(1)
+			return err
+		},
+		"borrowing record": func(tx repositories.Tx) error {
+			_, err := tx.Borrowings.GetByIDForUpdate(1)
+			return err
+		},
+	}
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			db, recorder := newRecordingDB(t)
+			if err := NewTransactor(db).WithinTransaction(read); err != nil {
+				t.Fatalf("WithinTransaction: %v", err)
+			}
+			if len(recorder.statements) != 3 || !strings.HasSuffix(recorder.statements[1], "FOR UPDATE") {
+				t.Errorf("statements = %q, want a SELECT ... FOR UPDATE inside the transaction", recorder.statements)
+			}
+		})
+	}
+}
+
+func TestTransactorRollsBackOnError(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	errAbort := errors.New("abort")
+
+	err := NewTransactor(db).WithinTransaction(func(tx repositories.Tx) error {
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction = %v, want %v", err, errAbort)
+	}
+	if got := strings.Join(recorder.statements, "; "); got != "BEGIN; ROLLBACK" {
+		t.Errorf("statements = %q, want BEGIN; ROLLBACK", got)
+	}
+}
+
+func TestTransactorCommitsOnSuccess(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+
+	if err := NewTransactor(db).WithinTransaction(func(tx repositories.Tx) error { return nil }); err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+	if got := strings.Join(recorder.statements, "; "); got != "BEGIN; COMMIT" {
+		t.Errorf("statements = %q, want BEGIN; COMMIT", got)
+	}
+}
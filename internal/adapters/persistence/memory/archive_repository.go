@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type ArchiveRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.ArchiveRepository = (*ArchiveRepository)(nil)
+
+func NewArchiveRepository(store *Store) *ArchiveRepository {
+	return &ArchiveRepository{store: store}
+}
+
+func (r *ArchiveRepository) Create(archive *models.BorrowingArchive) error {
+	r.store.access(r.inTx, func(st *state) {
+		if archive.ID == 0 {
+			st.nextArchiveID++
+			archive.ID = st.nextArchiveID
+		} else if archive.ID > st.nextArchiveID {
+			st.nextArchiveID = archive.ID
+		}
+		setRow(st, st.archives, archive.ID, *archive)
+	})
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type AuditRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository(store *Store) *AuditRepository {
+	return &AuditRepository{store: store}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	r.store.access(r.inTx, func(st *state) {
+		if entry.ID == 0 {
+			st.nextAuditID++
+			entry.ID = st.nextAuditID
+		} else if entry.ID > st.nextAuditID {
+			st.nextAuditID = entry.ID
+		}
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		setRow(st, st.audit, entry.ID, *entry)
+	})
+	return nil
+}
+
+func (r *AuditRepository) List(query repositories.AuditQuery) ([]models.AuditEntry, int64, error) {
+	entries := []models.AuditEntry{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, entry := range st.audit {
+			if query.Filter.Matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+
+	total := int64(len(entries))
+	if query.PageSize > 0 {
+		start := min(query.Offset(), len(entries))
+		end := min(start+query.PageSize, len(entries))
+		entries = entries[start:end]
+	}
+	return entries, total, nil
+}
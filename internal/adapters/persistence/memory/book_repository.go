@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type BookRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.BookRepository = (*BookRepository)(nil)
+
+func NewBookRepository(store *Store) *BookRepository {
+	return &BookRepository{store: store}
+}
+
+func (r *BookRepository) Create(book *models.Book) error {
+	if err := book.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	var err error
+	r.store.access(r.inTx, func(st *state) {
+		if err = st.checkISBNFree(book); err != nil {
+			return
+		}
+		if book.ID == 0 {
+			st.nextBookID++
+			book.ID = st.nextBookID
+		} else if book.ID > st.nextBookID {
+			st.nextBookID = book.ID
+		}
+		now := time.Now()
+		if book.CreatedAt.IsZero() {
+			book.CreatedAt = now
+		}
+		book.UpdatedAt = now
+		if book.Version == 0 {
+			book.Version = 1
+		}
+		st.saveContributors(book)
+		setRow(st, st.books, book.ID, stripAvailability(*book))
+	})
+	return err
+}
+
+func (r *BookRepository) GetAll() ([]models.Book, error) {
+	books := []models.Book{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, book := range st.books {
+			if !book.DeletedAt.Valid {
+				books = append(books, st.withAvailability(book))
+			}
+		}
+	})
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books, nil
+}
+
+func (r *BookRepository) List(query repositories.BookQuery) ([]models.Book, int64, error) {
+	books := []models.Book{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, book := range st.books {
+			book = st.withAvailability(book)
+			if (!book.DeletedAt.Valid || query.Filter.IncludeDeleted) && query.Filter.Matches(book) {
+				books = append(books, book)
+			}
+		}
+	})
+	sortBooks(books, query.Sort)
+
+	total := int64(len(books))
+	if query.PageSize > 0 {
+		start := min(query.Offset(), len(books))
+		end := min(start+query.PageSize, len(books))
+		books = books[start:end]
+	}
+	return books, total, nil
+}
+
+// EachBatch matches the books up front, then hands them out in batches
+// without holding the store, since fn may take a while.
+func (r *BookRepository) EachBatch(filter repositories.BookFilter, size int, fn func(books []models.Book) error) error {
+	books, _, err := r.List(repositories.BookQuery{Filter: filter})
+	if err != nil {
+		return err
+	}
+	return eachBatch(books, size, fn)
+}
+
+func (r *BookRepository) Search(text string, limit int) ([]repositories.BookMatch, error) {
+	books, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return searchBooks(books, text, limit), nil
+}
+
+func (r *BookRepository) Update(book *models.Book) error {
+	if book.ID == 0 {
+		return r.Create(book)
+	}
+	if err := book.BeforeUpdate(nil); err != nil {
+		return err
+	}
+
+	var err error
+	r.store.access(r.inTx, func(st *state) {
+		if err = st.checkISBNFree(book); err != nil {
+			return
+		}
+		book.UpdatedAt = time.Now()
+		st.saveContributors(book)
+		setRow(st, st.books, book.ID, stripAvailability(*book))
+		if book.ID > st.nextBookID {
+			st.nextBookID = book.ID
+		}
+	})
+	return err
+}
+
+// checkISBNFree mirrors the unique index on ISBN, which deleted books keep
+// holding.
+func (st *state) checkISBNFree(book *models.Book) error {
+	if book.ISBN == nil {
+		return nil
+	}
+	for id, existing := range st.books {
+		if id != book.ID && existing.ISBN != nil && *existing.ISBN == *book.ISBN {
+			return fmt.Errorf("%w: ISBN %s", repositories.ErrDuplicate, *book.ISBN)
+		}
+	}
+	return nil
+}
+
+func (r *BookRepository) Delete(id uint) error {
+	r.store.access(r.inTx, func(st *state) {
+		book, ok := st.books[id]
+		if !ok || book.DeletedAt.Valid {
+			return
+		}
+		book.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		setRow(st, st.books, id, book)
+	})
+	return nil
+}
+
+func (r *BookRepository) Restore(id uint) error {
+	r.store.access(r.inTx, func(st *state) {
+		if book, ok := st.books[id]; ok {
+			book.DeletedAt = gorm.DeletedAt{}
+			setRow(st, st.books, id, book)
+		}
+	})
+	return nil
+}
+
+func (r *BookRepository) Purge(id uint) error {
+	r.store.access(r.inTx, func(st *state) {
+		for recordID, record := range st.borrowings {
+			if record.BookID != id {
+				continue
+			}
+			for fineID, fine := range st.fines {
+				if fine.BorrowingRecordID == recordID {
+					deleteRow(st, st.fines, fineID)
+				}
+			}
+			deleteRow(st, st.borrowings, recordID)
+		}
+		for holdID, hold := range st.holds {
+			if hold.BookID == id {
+				deleteRow(st, st.holds, holdID)
+			}
+		}
+		for copyID, bookCopy := range st.copies {
+			if bookCopy.BookID == id {
+				deleteRow(st, st.copies, copyID)
+			}
+		}
+		deleteRow(st, st.books, id)
+	})
+	return nil
+}
+
+func (r *BookRepository) GetByID(id uint) (*models.Book, error) {
+	var found *models.Book
+	r.store.access(r.inTx, func(st *state) {
+		if book, ok := st.books[id]; ok && !book.DeletedAt.Valid {
+			book = st.withAvailability(book)
+			found = &book
+		}
+	})
+	return found, nil
+}
+
+func (r *BookRepository) GetByIDIncludingDeleted(id uint) (*models.Book, error) {
+	var found *models.Book
+	r.store.access(r.inTx, func(st *state) {
+		if book, ok := st.books[id]; ok {
+			book = st.withAvailability(book)
+			found = &book
+		}
+	})
+	return found, nil
+}
+
+func (r *BookRepository) GetByTitleAndAuthor(title, author string) (*models.Book, error) {
+	var found *models.Book
+	r.store.access(r.inTx, func(st *state) {
+		for _, book := range st.books {
+			if book.DeletedAt.Valid || !strings.EqualFold(book.Title, title) || !strings.EqualFold(book.Author, author) {
+				continue
+			}
+			if found == nil || book.ID < found.ID {
+				book = st.withAvailability(book)
+				found = &book
+			}
+		}
+	})
+	return found, nil
+}
+
+func (r *BookRepository) GetByISBN(isbn string) (*models.Book, error) {
+	var found *models.Book
+	r.store.access(r.inTx, func(st *state) {
+		for _, book := range st.books {
+			if book.ISBN != nil && *book.ISBN == isbn {
+				book = st.withAvailability(book)
+				found = &book
+				return
+			}
+		}
+	})
+	return found, nil
+}
+
+// GetByIDForUpdate needs no extra locking: inside a transaction the whole
+// store is already held by the caller.
+func (r *BookRepository) GetByIDForUpdate(id uint) (*models.Book, error) {
+	return r.GetByID(id)
+}
+
+// withAvailability mirrors the GORM adapter, which counts the available
+// copies of a book whenever it is read.
+func (st *state) withAvailability(book models.Book) models.Book {
+	book = stripAvailability(book)
+	for _, bookCopy := range st.copies {
+		if bookCopy.BookID == book.ID && bookCopy.Status == models.CopyStatusAvailable && !bookCopy.DeletedAt.Valid {
+			book.Availability++
+		}
+	}
+	return book
+}
+
+// stripAvailability drops the computed count before storing, since the GORM
+// adapter never writes it either. It also copies the pointer and slice
+// fields so the stored book shares no memory with the caller's.
+func stripAvailability(book models.Book) models.Book {
+	book.Availability = 0
+	if book.ISBN != nil {
+		isbn := *book.ISBN
+		book.ISBN = &isbn
+	}
+	book.Authors = slices.Clone(book.Authors)
+	book.Subjects = slices.Clone(book.Subjects)
+	return book
+}
+
+// saveContributors mirrors the GORM adapter: authors and subjects are found
+// by name, ignoring case like MySQL's default collation, or created.
+func (st *state) saveContributors(book *models.Book) {
+	for i, author := range book.Authors {
+		book.Authors[i] = models.Author{Name: author.Name}
+		for id, existing := range st.authors {
+			if strings.EqualFold(existing.Name, author.Name) {
+				book.Authors[i] = models.Author{ID: id, Name: existing.Name}
+			}
+		}
+		if book.Authors[i].ID == 0 {
+			st.nextAuthorID++
+			book.Authors[i].ID = st.nextAuthorID
+			setRow(st, st.authors, st.nextAuthorID, book.Authors[i])
+		}
+	}
+	for i, subject := range book.Subjects {
+		book.Subjects[i] = models.Subject{Name: subject.Name}
+		for id, existing := range st.subjects {
+			if strings.EqualFold(existing.Name, subject.Name) {
+				book.Subjects[i] = models.Subject{ID: id, Name: existing.Name}
+			}
+		}
+		if book.Subjects[i].ID == 0 {
+			st.nextSubjectID++
+			book.Subjects[i].ID = st.nextSubjectID
+			setRow(st, st.subjects, st.nextSubjectID, book.Subjects[i])
+		}
+	}
+}
+
+// sortBooks orders books like the GORM adapter: by each requested field in
+// turn, then by ID.
+func sortBooks(books []models.Book, sorts []repositories.BookSort) {
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, s := range sorts {
+			c := compareBooks(books[i], books[j], s.Field)
+			if c == 0 {
+				continue
+			}
+			if s.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return books[i].ID < books[j].ID
+	})
+}
+
+func compareBooks(a, b models.Book, field string) int {
+	switch field {
+	case repositories.BookSortTitle:
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case repositories.BookSortAuthor:
+		return strings.Compare(strings.ToLower(a.Author), strings.ToLower(b.Author))
+	case repositories.BookSortGenre:
+		return strings.Compare(strings.ToLower(a.Genre), strings.ToLower(b.Genre))
+	case repositories.BookSortPublicationDate:
+		return time.Time(a.PublicationDate).Compare(time.Time(b.PublicationDate))
+	case repositories.BookSortAvailability:
+		return cmp.Compare(a.Availability, b.Availability)
+	case repositories.BookSortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return 0
+}
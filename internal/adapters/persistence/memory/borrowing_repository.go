@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"sort"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type BorrowingRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.BorrowingRepository = (*BorrowingRepository)(nil)
+
+func NewBorrowingRepository(store *Store) *BorrowingRepository {
+	return &BorrowingRepository{store: store}
+}
+
+func (r *BorrowingRepository) Create(borrowingRecord *models.BorrowingRecord) error {
+	r.store.access(r.inTx, func(st *state) {
+		if borrowingRecord.ID == 0 {
+			st.nextBorrowingID++
+			borrowingRecord.ID = st.nextBorrowingID
+		} else if borrowingRecord.ID > st.nextBorrowingID {
+			st.nextBorrowingID = borrowingRecord.ID
+		}
+		setRow(st, st.borrowings, borrowingRecord.ID, stripBook(*borrowingRecord))
+	})
+	return nil
+}
+
+func (r *BorrowingRepository) GetByID(id uint) (*models.BorrowingRecord, error) {
+	var found *models.BorrowingRecord
+	r.store.access(r.inTx, func(st *state) {
+		if record, ok := st.borrowings[id]; ok {
+			record = st.preloadBook(record)
+			found = &record
+		}
+	})
+	return found, nil
+}
+
+// GetByIDForUpdate returns the record without its Book, like the GORM adapter.
+func (r *BorrowingRepository) GetByIDForUpdate(id uint) (*models.BorrowingRecord, error) {
+	var found *models.BorrowingRecord
+	r.store.access(r.inTx, func(st *state) {
+		if record, ok := st.borrowings[id]; ok {
+			record = stripBook(record)
+			found = &record
+		}
+	})
+	return found, nil
+}
+
+func (r *BorrowingRepository) List(filter repositories.BorrowingFilter) ([]models.BorrowingRecord, error) {
+	return r.list(filter.Matches), nil
+}
+
+func (r *BorrowingRepository) EachBatch(filter repositories.BorrowingFilter, size int, fn func(records []models.BorrowingRecord) error) error {
+	return eachBatch(r.list(filter.Matches), size, fn)
+}
+
+func (r *BorrowingRepository) Update(borrowingRecord *models.BorrowingRecord) error {
+	if borrowingRecord.ID == 0 {
+		return r.Create(borrowingRecord)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		setRow(st, st.borrowings, borrowingRecord.ID, stripBook(*borrowingRecord))
+	})
+	return nil
+}
+
+func (r *BorrowingRepository) list(match func(models.BorrowingRecord) bool) []models.BorrowingRecord {
+	records := []models.BorrowingRecord{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, record := range st.borrowings {
+			if match(record) {
+				records = append(records, st.preloadBook(record))
+			}
+		}
+	})
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records
+}
+
+// preloadBook mirrors Preload("Book").Preload("Copy"): soft-deleted books and
+// copies are not loaded and leave the association empty.
+func (st *state) preloadBook(record models.BorrowingRecord) models.BorrowingRecord {
+	if book, ok := st.books[record.BookID]; ok && !book.DeletedAt.Valid {
+		record.Book = st.withAvailability(book)
+	}
+	if record.CopyID != nil {
+		if bookCopy, ok := st.copies[*record.CopyID]; ok && !bookCopy.DeletedAt.Valid {
+			record.Copy = &bookCopy
+		}
+	}
+	return record
+}
+
+// stripBook drops the associations before storing, so a stale copy of the
+// book is never kept alongside the record. It also copies the pointer fields
+// so the stored record shares no memory with the caller's.
+func stripBook(record models.BorrowingRecord) models.BorrowingRecord {
+	record.Book = models.Book{}
+	record.Copy = nil
+	record.Overdue = false
+	if record.CopyID != nil {
+		copyID := *record.CopyID
+		record.CopyID = &copyID
+	}
+	if record.ReturnDate != nil {
+		returnDate := *record.ReturnDate
+		record.ReturnDate = &returnDate
+	}
+	if record.OverdueNotifiedAt != nil {
+		notifiedAt := *record.OverdueNotifiedAt
+		record.OverdueNotifiedAt = &notifiedAt
+	}
+	return record
+}
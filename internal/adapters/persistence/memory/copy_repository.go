@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type CopyRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.CopyRepository = (*CopyRepository)(nil)
+
+func NewCopyRepository(store *Store) *CopyRepository {
+	return &CopyRepository{store: store}
+}
+
+// Create enforces the unique barcode index of the GORM adapter.
+func (r *CopyRepository) Create(bookCopy *models.Copy) error {
+	var err error
+	r.store.access(r.inTx, func(st *state) {
+		for _, existing := range st.copies {
+			if strings.EqualFold(existing.Barcode, bookCopy.Barcode) {
+				err = fmt.Errorf("%w: barcode %q", repositories.ErrDuplicate, bookCopy.Barcode)
+				return
+			}
+		}
+
+		if bookCopy.ID == 0 {
+			st.nextCopyID++
+			bookCopy.ID = st.nextCopyID
+		} else if bookCopy.ID > st.nextCopyID {
+			st.nextCopyID = bookCopy.ID
+		}
+		now := time.Now()
+		bookCopy.CreatedAt = now
+		bookCopy.UpdatedAt = now
+		if bookCopy.Status == "" {
+			bookCopy.Status = models.CopyStatusAvailable
+		}
+		if bookCopy.Condition == "" {
+			bookCopy.Condition = models.CopyConditionGood
+		}
+		setRow(st, st.copies, bookCopy.ID, *bookCopy)
+	})
+	return err
+}
+
+func (r *CopyRepository) GetByID(id uint) (*models.Copy, error) {
+	var found *models.Copy
+	r.store.access(r.inTx, func(st *state) {
+		if bookCopy, ok := st.copies[id]; ok && !bookCopy.DeletedAt.Valid {
+			found = &bookCopy
+		}
+	})
+	return found, nil
+}
+
+func (r *CopyRepository) GetByIDForUpdate(id uint) (*models.Copy, error) {
+	return r.GetByID(id)
+}
+
+func (r *CopyRepository) List(filter repositories.CopyFilter) ([]models.Copy, error) {
+	copies := []models.Copy{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, bookCopy := range st.copies {
+			if !bookCopy.DeletedAt.Valid && filter.Matches(bookCopy) {
+				copies = append(copies, bookCopy)
+			}
+		}
+	})
+	sort.Slice(copies, func(i, j int) bool { return copies[i].ID < copies[j].ID })
+	return copies, nil
+}
+
+func (r *CopyRepository) Update(bookCopy *models.Copy) error {
+	if bookCopy.ID == 0 {
+		return r.Create(bookCopy)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		bookCopy.UpdatedAt = time.Now()
+		setRow(st, st.copies, bookCopy.ID, *bookCopy)
+	})
+	return nil
+}
+
+func (r *CopyRepository) NextAvailableForUpdate(bookID uint) (*models.Copy, error) {
+	copies, err := r.List(repositories.CopyFilter{BookID: bookID, Status: models.CopyStatusAvailable})
+	if err != nil || len(copies) == 0 {
+		return nil, err
+	}
+	return &copies[0], nil
+}
+
+func (r *CopyRepository) CountForBook(bookID uint) (int64, error) {
+	var count int64
+	r.store.access(r.inTx, func(st *state) {
+		for _, bookCopy := range st.copies {
+			if bookCopy.BookID == bookID {
+				count++
+			}
+		}
+	})
+	return count, nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type FineRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.FineRepository = (*FineRepository)(nil)
+
+func NewFineRepository(store *Store) *FineRepository {
+	return &FineRepository{store: store}
+}
+
+func (r *FineRepository) Create(fine *models.Fine) error {
+	r.store.access(r.inTx, func(st *state) {
+		if fine.ID == 0 {
+			st.nextFineID++
+			fine.ID = st.nextFineID
+		} else if fine.ID > st.nextFineID {
+			st.nextFineID = fine.ID
+		}
+		now := time.Now()
+		fine.CreatedAt = now
+		fine.UpdatedAt = now
+		if fine.Status == "" {
+			fine.Status = models.FineStatusUnpaid
+		}
+		setRow(st, st.fines, fine.ID, detachFine(*fine))
+	})
+	return nil
+}
+
+func (r *FineRepository) GetByID(id uint) (*models.Fine, error) {
+	var found *models.Fine
+	r.store.access(r.inTx, func(st *state) {
+		if fine, ok := st.fines[id]; ok && !fine.DeletedAt.Valid {
+			fine = st.preloadBorrowingRecord(fine)
+			found = &fine
+		}
+	})
+	return found, nil
+}
+
+// GetByIDForUpdate returns the fine without its BorrowingRecord, like the
+// GORM adapter.
+func (r *FineRepository) GetByIDForUpdate(id uint) (*models.Fine, error) {
+	var found *models.Fine
+	r.store.access(r.inTx, func(st *state) {
+		if fine, ok := st.fines[id]; ok && !fine.DeletedAt.Valid {
+			fine = detachFine(fine)
+			found = &fine
+		}
+	})
+	return found, nil
+}
+
+func (r *FineRepository) List(filter repositories.FineFilter) ([]models.Fine, error) {
+	fines := []models.Fine{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, fine := range st.fines {
+			if !fine.DeletedAt.Valid && filter.Matches(fine) {
+				fines = append(fines, st.preloadBorrowingRecord(fine))
+			}
+		}
+	})
+	sort.Slice(fines, func(i, j int) bool { return fines[i].ID < fines[j].ID })
+	return fines, nil
+}
+
+func (r *FineRepository) Update(fine *models.Fine) error {
+	if fine.ID == 0 {
+		return r.Create(fine)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		fine.UpdatedAt = time.Now()
+		setRow(st, st.fines, fine.ID, detachFine(*fine))
+	})
+	return nil
+}
+
+func (r *FineRepository) OutstandingBalance(memberID uint) (int64, error) {
+	var balance int64
+	r.store.access(r.inTx, func(st *state) {
+		for _, fine := range st.fines {
+			if !fine.DeletedAt.Valid && fine.MemberID == memberID {
+				balance += fine.OutstandingCents()
+			}
+		}
+	})
+	return balance, nil
+}
+
+// preloadBorrowingRecord mirrors Preload("BorrowingRecord.Book").
+func (st *state) preloadBorrowingRecord(fine models.Fine) models.Fine {
+	fine = detachFine(fine)
+	if record, ok := st.borrowings[fine.BorrowingRecordID]; ok {
+		fine.BorrowingRecord = st.preloadBook(stripBook(record))
+		fine.BorrowingRecord.Copy = nil
+	}
+	return fine
+}
+
+// detachFine drops the association and copies pointer fields so the stored
+// fine shares no memory with the caller's.
+func detachFine(fine models.Fine) models.Fine {
+	fine.BorrowingRecord = models.BorrowingRecord{}
+	if fine.ResolvedAt != nil {
+		resolvedAt := *fine.ResolvedAt
+		fine.ResolvedAt = &resolvedAt
+	}
+	return fine
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type HoldRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.HoldRepository = (*HoldRepository)(nil)
+
+func NewHoldRepository(store *Store) *HoldRepository {
+	return &HoldRepository{store: store}
+}
+
+func (r *HoldRepository) Create(hold *models.Hold) error {
+	r.store.access(r.inTx, func(st *state) {
+		if hold.ID == 0 {
+			st.nextHoldID++
+			hold.ID = st.nextHoldID
+		} else if hold.ID > st.nextHoldID {
+			st.nextHoldID = hold.ID
+		}
+		now := time.Now()
+		hold.CreatedAt = now
+		hold.UpdatedAt = now
+		if hold.Status == "" {
+			hold.Status = models.HoldStatusWaiting
+		}
+		setRow(st, st.holds, hold.ID, detachHold(*hold))
+	})
+	return nil
+}
+
+func (r *HoldRepository) GetByID(id uint) (*models.Hold, error) {
+	var found *models.Hold
+	r.store.access(r.inTx, func(st *state) {
+		if hold, ok := st.holds[id]; ok && !hold.DeletedAt.Valid {
+			hold = st.preloadHoldBook(hold)
+			found = &hold
+		}
+	})
+	return found, nil
+}
+
+// GetByIDForUpdate returns the hold without its Book, like the GORM adapter.
+func (r *HoldRepository) GetByIDForUpdate(id uint) (*models.Hold, error) {
+	var found *models.Hold
+	r.store.access(r.inTx, func(st *state) {
+		if hold, ok := st.holds[id]; ok && !hold.DeletedAt.Valid {
+			hold = detachHold(hold)
+			found = &hold
+		}
+	})
+	return found, nil
+}
+
+func (r *HoldRepository) List(filter repositories.HoldFilter) ([]models.Hold, error) {
+	holds := []models.Hold{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, hold := range st.holds {
+			if !hold.DeletedAt.Valid && filter.Matches(hold) {
+				holds = append(holds, st.preloadHoldBook(hold))
+			}
+		}
+	})
+	sort.Slice(holds, func(i, j int) bool { return holds[i].ID < holds[j].ID })
+	return holds, nil
+}
+
+func (r *HoldRepository) Update(hold *models.Hold) error {
+	if hold.ID == 0 {
+		return r.Create(hold)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		hold.UpdatedAt = time.Now()
+		setRow(st, st.holds, hold.ID, detachHold(*hold))
+	})
+	return nil
+}
+
+// preloadHoldBook mirrors Preload("Book").
+func (st *state) preloadHoldBook(hold models.Hold) models.Hold {
+	hold = detachHold(hold)
+	if book, ok := st.books[hold.BookID]; ok && !book.DeletedAt.Valid {
+		hold.Book = st.withAvailability(book)
+	}
+	return hold
+}
+
+// detachHold drops the association and computed fields and copies pointer
+// fields so the stored hold shares no memory with the caller's.
+func detachHold(hold models.Hold) models.Hold {
+	hold.Book = models.Book{}
+	hold.Position = 0
+	if hold.ReadyAt != nil {
+		readyAt := *hold.ReadyAt
+		hold.ReadyAt = &readyAt
+	}
+	if hold.ExpiresAt != nil {
+		expiresAt := *hold.ExpiresAt
+		hold.ExpiresAt = &expiresAt
+	}
+	return hold
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type OutboxRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.OutboxRepository = (*OutboxRepository)(nil)
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+func (r *OutboxRepository) Create(event *models.OutboxEvent) error {
+	r.store.access(r.inTx, func(st *state) {
+		if event.ID == 0 {
+			st.nextOutboxID++
+			event.ID = st.nextOutboxID
+		} else if event.ID > st.nextOutboxID {
+			st.nextOutboxID = event.ID
+		}
+		setRow(st, st.outbox, event.ID, *event)
+	})
+	return nil
+}
+
+// ListDueForUpdate relies on the transaction holding the store, which no
+// other dispatcher can then claim events from.
+func (r *OutboxRepository) ListDueForUpdate(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	events := []models.OutboxEvent{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, event := range st.outbox {
+			if event.PublishedAt == nil && !event.NextAttemptAt.After(now) {
+				events = append(events, event)
+			}
+		}
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) Update(event *models.OutboxEvent) error {
+	if event.ID == 0 {
+		return r.Create(event)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		setRow(st, st.outbox, event.ID, *event)
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// Field weights: a hit in the title counts more than one in the genre.
+const (
+	titleWeight  = 3.0
+	authorWeight = 2.0
+	genreWeight  = 1.0
+	// A query term that is only a prefix of a word scores this fraction of
+	// an exact hit, so "tolk" still finds "Tolkien".
+	prefixFactor = 0.5
+)
+
+// tokenize lower-cases text and splits it into words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+type indexedBook struct {
+	book   models.Book
+	fields [3][]string
+}
+
+var fieldWeights = [3]float64{titleWeight, authorWeight, genreWeight}
+
+// searchBooks scores books by TF-IDF over the title, author and genre: rare
+// terms weigh more than terms found in most books.
+func searchBooks(books []models.Book, text string, limit int) []repositories.BookMatch {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return []repositories.BookMatch{}
+	}
+
+	indexed := make([]indexedBook, len(books))
+	for i, book := range books {
+		indexed[i] = indexedBook{
+			book:   book,
+			fields: [3][]string{tokenize(book.Title), tokenize(book.Author), tokenize(book.Genre)},
+		}
+	}
+
+	idf := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		df := 0
+		for _, ib := range indexed {
+			if ib.contains(term) {
+				df++
+			}
+		}
+		idf[term] = math.Log(1 + float64(len(indexed))/float64(df+1))
+	}
+
+	matches := []repositories.BookMatch{}
+	for _, ib := range indexed {
+		score := 0.0
+		for _, term := range terms {
+			for f, words := range ib.fields {
+				score += fieldWeights[f] * termFrequency(words, term) * idf[term]
+			}
+		}
+		if score > 0 {
+			matches = append(matches, repositories.BookMatch{Book: ib.book, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Book.ID < matches[j].Book.ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func (ib indexedBook) contains(term string) bool {
+	for _, words := range ib.fields {
+		if termFrequency(words, term) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func termFrequency(words []string, term string) float64 {
+	tf := 0.0
+	for _, word := range words {
+		switch {
+		case word == term:
+			tf++
+		case strings.HasPrefix(word, term):
+			tf += prefixFactor
+		}
+	}
+	return tf
+}
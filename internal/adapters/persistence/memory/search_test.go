@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"testing"
+
+	"hex/pkg/models"
+)
+
+func searchable(id uint, title, author, genre string) models.Book {
+	book := models.Book{Title: title, Author: author, Genre: genre}
+	book.ID = id
+	return book
+}
+
+func searchTitles(books []models.Book, text string, limit int) []string {
+	titles := []string{}
+	for _, match := range searchBooks(books, text, limit) {
+		titles = append(titles, match.Book.Title)
+	}
+	return titles
+}
+
+func TestSearchRanksTitleAboveAuthorAboveGenre(t *testing.T) {
+	books := []models.Book{
+		searchable(1, "Planet of Exile", "Ursula K. Le Guin", "Earthsea"),
+		searchable(2, "Rocannon's World", "Earthsea Press", "Science Fiction"),
+		searchable(3, "A Wizard of Earthsea", "Ursula K. Le Guin", "Fantasy"),
+		searchable(4, "The Dispossessed", "Ursula K. Le Guin", "Science Fiction"),
+	}
+
+	got := searchTitles(books, "earthsea", 0)
+	want := []string{"A Wizard of Earthsea", "Rocannon's World", "Planet of Exile"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("search earthsea = %q, want %q", got, want)
+	}
+}
+
+func TestSearchTokenizesAndMatchesPrefixes(t *testing.T) {
+	books := []models.Book{
+		searchable(1, "The Hobbit", "J.R.R. Tolkien", "Fantasy"),
+		searchable(2, "The Lord of the Rings", "J.R.R. Tolkien", "Fantasy"),
+		searchable(3, "Dune", "Frank Herbert", "Science Fiction"),
+	}
+
+	if got := searchTitles(books, "TOLK", 0); len(got) != 2 {
+		t.Errorf("search TOLK = %q, want both Tolkien books", got)
+	}
+	if got := searchTitles(books, "lord, rings!", 0); len(got) != 1 || got[0] != "The Lord of the Rings" {
+		t.Errorf("search \"lord, rings!\" = %q, want The Lord of the Rings", got)
+	}
+	if got := searchTitles(books, "fantasy", 1); len(got) != 1 || got[0] != "The Hobbit" {
+		t.Errorf("search fantasy limited to 1 = %q, want the lowest ID among equal scores", got)
+	}
+	if got := searchTitles(books, " ,; ", 0); len(got) != 0 {
+		t.Errorf("search of punctuation = %q, want nothing", got)
+	}
+}
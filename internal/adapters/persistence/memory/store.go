@@ -0,0 +1,160 @@
+// Package memory provides thread-safe in-memory implementations of the
+// repository ports. They mirror the GORM adapters closely enough to run the
+// API without a database, for local development and tests.
+package memory
+
+import (
+	"sync"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// Store holds every table kept in memory. Repositories created from the same
+// Store see each other's writes, just like repositories sharing a database.
+type Store struct {
+	mu    sync.Mutex
+	state *state
+}
+
+type state struct {
+	books           map[uint]models.Book
+	authors         map[uint]models.Author
+	subjects        map[uint]models.Subject
+	copies          map[uint]models.Copy
+	borrowings      map[uint]models.BorrowingRecord
+	fines           map[uint]models.Fine
+	holds           map[uint]models.Hold
+	archives        map[uint]models.BorrowingArchive
+	audit           map[uint]models.AuditEntry
+	outbox          map[uint]models.OutboxEvent
+	webhooks        map[uint]models.WebhookSubscription
+	deliveries      map[uint]models.WebhookDelivery
+	nextBookID      uint
+	nextAuthorID    uint
+	nextSubjectID   uint
+	nextCopyID      uint
+	nextBorrowingID uint
+	nextFineID      uint
+	nextHoldID      uint
+	nextArchiveID   uint
+	nextAuditID     uint
+	nextOutboxID    uint
+	nextWebhookID   uint
+	nextDeliveryID  uint
+	// undo holds, while a transaction runs, funcs reverting each row it
+	// wrote, oldest first. It is nil outside transactions.
+	undo []func()
+}
+
+func NewStore() *Store {
+	return &Store{
+		state: &state{
+			books:      map[uint]models.Book{},
+			authors:    map[uint]models.Author{},
+			subjects:   map[uint]models.Subject{},
+			copies:     map[uint]models.Copy{},
+			borrowings: map[uint]models.BorrowingRecord{},
+			fines:      map[uint]models.Fine{},
+			holds:      map[uint]models.Hold{},
+			archives:   map[uint]models.BorrowingArchive{},
+			audit:      map[uint]models.AuditEntry{},
+			outbox:     map[uint]models.OutboxEvent{},
+			webhooks:   map[uint]models.WebhookSubscription{},
+			deliveries: map[uint]models.WebhookDelivery{},
+		},
+	}
+}
+
+// setRow stores row under id in table, one of the maps of st, logging how to
+// undo it while a transaction runs.
+func setRow[T any](st *state, table map[uint]T, id uint, row T) {
+	if st.undo != nil {
+		st.undo = append(st.undo, undoFor(table, id))
+	}
+	table[id] = row
+}
+
+// deleteRow removes id from table, one of the maps of st, logging how to undo
+// it while a transaction runs.
+func deleteRow[T any](st *state, table map[uint]T, id uint) {
+	if st.undo != nil {
+		st.undo = append(st.undo, undoFor(table, id))
+	}
+	delete(table, id)
+}
+
+// undoFor returns a func putting table's current row for id, or its absence,
+// back.
+func undoFor[T any](table map[uint]T, id uint) func() {
+	row, ok := table[id]
+	if !ok {
+		return func() { delete(table, id) }
+	}
+	return func() { table[id] = row }
+}
+
+// eachBatch calls fn with successive slices of at most size items.
+func eachBatch[T any](items []T, size int, fn func([]T) error) error {
+	for start := 0; start < len(items); start += size {
+		if err := fn(items[start:min(start+size, len(items))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// access runs fn with exclusive access to the store state. Repositories bound
+// to a transaction already hold the lock, so they skip taking it again.
+func (s *Store) access(inTx bool, fn func(st *state)) {
+	if !inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	fn(s.state)
+}
+
+// Transactor runs a unit of work with the store locked for its whole
+// duration, which gives the same isolation as SELECT ... FOR UPDATE on every
+// row. When fn fails, the rows it wrote are reverted from the undo log and
+// the ID counters reset, restoring the store to its state before the call.
+type Transactor struct {
+	store *Store
+}
+
+var _ repositories.Transactor = (*Transactor)(nil)
+
+func NewTransactor(store *Store) *Transactor {
+	return &Transactor{store: store}
+}
+
+func (t *Transactor) WithinTransaction(fn func(tx repositories.Tx) error) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	st := t.store.state
+	counters := *st
+	st.undo = []func(){}
+	defer func() { st.undo = nil }()
+
+	err := fn(repositories.Tx{
+		Books:      &BookRepository{store: t.store, inTx: true},
+		Copies:     &CopyRepository{store: t.store, inTx: true},
+		Borrowings: &BorrowingRepository{store: t.store, inTx: true},
+		Fines:      &FineRepository{store: t.store, inTx: true},
+		Holds:      &HoldRepository{store: t.store, inTx: true},
+		Archives:   &ArchiveRepository{store: t.store, inTx: true},
+		Audit:      &AuditRepository{store: t.store, inTx: true},
+		Outbox:     &OutboxRepository{store: t.store, inTx: true},
+		Webhooks:   &WebhookRepository{store: t.store, inTx: true},
+		Deliveries: &WebhookDeliveryRepository{store: t.store, inTx: true},
+	})
+	if err != nil {
+		for i := len(st.undo) - 1; i >= 0; i-- {
+			st.undo[i]()
+		}
+		// The maps are shared with counters, so this only resets the counters
+		*st = counters
+	}
+	return err
+}
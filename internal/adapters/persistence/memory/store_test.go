@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+func TestTransactorRollsBackWrites(t *testing.T) {
+	store := NewStore()
+	books := NewBookRepository(store)
+	kept := &models.Book{Title: "The Tombs of Atuan", Author: "Ursula K. Le Guin"}
+	if err := books.Create(kept); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err := NewTransactor(store).WithinTransaction(func(tx repositories.Tx) error {
+		changed := *kept
+		changed.Title = "The Farthest Shore"
+		if err := tx.Books.Update(&changed); err != nil {
+			return err
+		}
+		if err := tx.Books.Create(&models.Book{Title: "Tehanu", Author: "Ursula K. Le Guin"}); err != nil {
+			return err
+		}
+		if err := tx.Copies.Create(&models.Copy{BookID: kept.ID, Barcode: "B1-0001"}); err != nil {
+			return err
+		}
+		if err := tx.Books.Purge(kept.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction = %v, want %v", err, errAbort)
+	}
+
+	got, err := books.GetByID(kept.ID)
+	if err != nil || got == nil || got.Title != kept.Title {
+		t.Fatalf("GetByID after rollback = %+v, %v; want %q back", got, err, kept.Title)
+	}
+	all, _ := books.GetAll()
+	copies, _ := NewCopyRepository(store).List(repositories.CopyFilter{})
+	if len(all) != 1 || len(copies) != 0 {
+		t.Errorf("after rollback: %d books, %d copies; want 1, 0", len(all), len(copies))
+	}
+
+	next := &models.Book{Title: "Tales from Earthsea", Author: "Ursula K. Le Guin"}
+	if err := books.Create(next); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if next.ID != kept.ID+1 {
+		t.Errorf("next book got ID %d, want the rolled back ID %d reused", next.ID, kept.ID+1)
+	}
+	if store.state.undo != nil {
+		t.Errorf("undo log still set outside a transaction")
+	}
+}
+
+func TestTransactorKeepsCommittedWrites(t *testing.T) {
+	store := NewStore()
+	book := &models.Book{Title: "The Other Wind", Author: "Ursula K. Le Guin"}
+	err := NewTransactor(store).WithinTransaction(func(tx repositories.Tx) error {
+		return tx.Books.Create(book)
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+	if got, err := NewBookRepository(store).GetByID(book.ID); err != nil || got == nil {
+		t.Errorf("GetByID after commit = %v, %v; want the book", got, err)
+	}
+	if store.state.undo != nil {
+		t.Errorf("undo log still set outside a transaction")
+	}
+}
+
+func TestBorrowingRecordSharesNoMemoryWithCaller(t *testing.T) {
+	store := NewStore()
+	borrowings := NewBorrowingRepository(store)
+	returned := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	notified := returned.Add(-time.Hour)
+	copyID, returnDate, notifiedAt := uint(3), returned, notified
+	record := &models.BorrowingRecord{BookID: 1, MemberID: 2, CopyID: &copyID, ReturnDate: &returnDate, OverdueNotifiedAt: &notifiedAt}
+	if err := borrowings.Create(record); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	*record.CopyID = 4
+	*record.ReturnDate = returned.Add(time.Hour)
+	*record.OverdueNotifiedAt = notified.Add(time.Hour)
+
+	stored, err := borrowings.GetByID(record.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetByID = %+v, %v; want the record", stored, err)
+	}
+	if *stored.CopyID != 3 || !stored.ReturnDate.Equal(returned) || !stored.OverdueNotifiedAt.Equal(notified) {
+		t.Errorf("stored record changed through the caller's pointers: copy %d, returned %v, notified %v", *stored.CopyID, *stored.ReturnDate, *stored.OverdueNotifiedAt)
+	}
+}
+
+func TestDeletedBookIsHidden(t *testing.T) {
+	books := NewBookRepository(NewStore())
+	book := &models.Book{Title: "The Lathe of Heaven", Author: "Ursula K. Le Guin"}
+	if err := books.Create(book); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := books.Delete(book.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if got, err := books.GetByID(book.ID); got != nil || err != nil {
+		t.Errorf("GetByID = %+v, %v; want nil, nil", got, err)
+	}
+	if all, err := books.GetAll(); err != nil || len(all) != 0 {
+		t.Errorf("GetAll = %d books, %v; want the deleted book left out", len(all), err)
+	}
+}
+
+func TestDeletedBookKeepsRow(t *testing.T) {
+	books := NewBookRepository(NewStore())
+	book := &models.Book{Title: "The Lathe of Heaven", Author: "Ursula K. Le Guin"}
+	if err := books.Create(book); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := books.Delete(book.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	got, err := books.GetByIDIncludingDeleted(book.ID)
+	if err != nil || got == nil || !got.DeletedAt.Valid || got.Title != book.Title {
+		t.Errorf("GetByIDIncludingDeleted = %+v, %v; want the book with DeletedAt set", got, err)
+	}
+}
+
+func TestRestoreBookClearsDeletedAt(t *testing.T) {
+	books := NewBookRepository(NewStore())
+	book := &models.Book{Title: "The Lathe of Heaven", Author: "Ursula K. Le Guin"}
+	if err := books.Create(book); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := books.Delete(book.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := books.Restore(book.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got, err := books.GetByID(book.ID); err != nil || got == nil || got.DeletedAt.Valid {
+		t.Errorf("GetByID after restore = %+v, %v; want the book", got, err)
+	}
+}
+
+func TestGetBookByIDNotFound(t *testing.T) {
+	if got, err := NewBookRepository(NewStore()).GetByID(42); got != nil || err != nil {
+		t.Errorf("GetByID(42) = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestGetBorrowingRecordByIDNotFound(t *testing.T) {
+	if got, err := NewBorrowingRepository(NewStore()).GetByID(42); got != nil || err != nil {
+		t.Errorf("GetByID(42) = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestBorrowingRecordPreloadsBook(t *testing.T) {
+	store := NewStore()
+	book := &models.Book{Title: "A Wizard of Earthsea", Author: "Ursula K. Le Guin"}
+	if err := NewBookRepository(store).Create(book); err != nil {
+		t.Fatalf("Create book: %v", err)
+	}
+	borrowings := NewBorrowingRepository(store)
+	record := &models.BorrowingRecord{BookID: book.ID, MemberID: 10}
+	if err := borrowings.Create(record); err != nil {
+		t.Fatalf("Create record: %v", err)
+	}
+
+	got, err := borrowings.GetByID(record.ID)
+	if err != nil || got == nil || got.Book.ID != book.ID || got.Book.Title != book.Title {
+		t.Errorf("GetByID = %+v, %v; want the record with its book", got, err)
+	}
+	listed, err := borrowings.List(repositories.BorrowingFilter{MemberID: 10})
+	if err != nil || len(listed) != 1 || listed[0].Book.Title != book.Title {
+		t.Errorf("List = %+v, %v; want the record with its book", listed, err)
+	}
+}
+
+func TestBorrowingRecordReadsBookWhenRead(t *testing.T) {
+	store := NewStore()
+	books := NewBookRepository(store)
+	book := &models.Book{Title: "A Wizard of Earthsea", Author: "Ursula K. Le Guin"}
+	if err := books.Create(book); err != nil {
+		t.Fatalf("Create book: %v", err)
+	}
+	borrowings := NewBorrowingRepository(store)
+	record := &models.BorrowingRecord{BookID: book.ID, MemberID: 10, Book: *book}
+	if err := borrowings.Create(record); err != nil {
+		t.Fatalf("Create record: %v", err)
+	}
+
+	book.Title = "The Farthest Shore"
+	if err := books.Update(book); err != nil {
+		t.Fatalf("Update book: %v", err)
+	}
+	got, err := borrowings.GetByID(record.ID)
+	if err != nil || got == nil || got.Book.Title != book.Title {
+		t.Errorf("GetByID = %+v, %v; want the book as renamed", got, err)
+	}
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{store: store}
+}
+
+func (r *WebhookRepository) Create(subscription *models.WebhookSubscription) error {
+	r.store.access(r.inTx, func(st *state) {
+		if subscription.ID == 0 {
+			st.nextWebhookID++
+			subscription.ID = st.nextWebhookID
+		} else if subscription.ID > st.nextWebhookID {
+			st.nextWebhookID = subscription.ID
+		}
+		now := time.Now()
+		subscription.CreatedAt = now
+		subscription.UpdatedAt = now
+		setRow(st, st.webhooks, subscription.ID, detachWebhook(*subscription))
+	})
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(id uint) (*models.WebhookSubscription, error) {
+	var found *models.WebhookSubscription
+	r.store.access(r.inTx, func(st *state) {
+		if subscription, ok := st.webhooks[id]; ok && !subscription.DeletedAt.Valid {
+			subscription = detachWebhook(subscription)
+			found = &subscription
+		}
+	})
+	return found, nil
+}
+
+func (r *WebhookRepository) GetByIDForUpdate(id uint) (*models.WebhookSubscription, error) {
+	return r.GetByID(id)
+}
+
+func (r *WebhookRepository) List(activeOnly bool) ([]models.WebhookSubscription, error) {
+	subscriptions := []models.WebhookSubscription{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, subscription := range st.webhooks {
+			if !subscription.DeletedAt.Valid && (subscription.Active || !activeOnly) {
+				subscriptions = append(subscriptions, detachWebhook(subscription))
+			}
+		}
+	})
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) Update(subscription *models.WebhookSubscription) error {
+	if subscription.ID == 0 {
+		return r.Create(subscription)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		subscription.UpdatedAt = time.Now()
+		setRow(st, st.webhooks, subscription.ID, detachWebhook(*subscription))
+	})
+	return nil
+}
+
+func (r *WebhookRepository) Delete(id uint) error {
+	r.store.access(r.inTx, func(st *state) {
+		subscription, ok := st.webhooks[id]
+		if !ok || subscription.DeletedAt.Valid {
+			return
+		}
+		subscription.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		setRow(st, st.webhooks, id, subscription)
+	})
+	return nil
+}
+
+// detachWebhook copies the event types so the stored subscription shares no
+// memory with the caller's.
+func detachWebhook(subscription models.WebhookSubscription) models.WebhookSubscription {
+	subscription.EventTypes = slices.Clone(subscription.EventTypes)
+	return subscription
+}
+
+type WebhookDeliveryRepository struct {
+	store *Store
+	inTx  bool
+}
+
+var _ repositories.WebhookDeliveryRepository = (*WebhookDeliveryRepository)(nil)
+
+func NewWebhookDeliveryRepository(store *Store) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{store: store}
+}
+
+func (r *WebhookDeliveryRepository) Create(delivery *models.WebhookDelivery) error {
+	r.store.access(r.inTx, func(st *state) {
+		if delivery.ID == 0 {
+			st.nextDeliveryID++
+			delivery.ID = st.nextDeliveryID
+		} else if delivery.ID > st.nextDeliveryID {
+			st.nextDeliveryID = delivery.ID
+		}
+		now := time.Now()
+		delivery.CreatedAt = now
+		delivery.UpdatedAt = now
+		if delivery.Status == "" {
+			delivery.Status = models.WebhookDeliveryPending
+		}
+		setRow(st, st.deliveries, delivery.ID, detachDelivery(*delivery))
+	})
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) GetByIDForUpdate(id uint) (*models.WebhookDelivery, error) {
+	var found *models.WebhookDelivery
+	r.store.access(r.inTx, func(st *state) {
+		if delivery, ok := st.deliveries[id]; ok {
+			delivery = detachDelivery(delivery)
+			found = &delivery
+		}
+	})
+	return found, nil
+}
+
+func (r *WebhookDeliveryRepository) List(query repositories.WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error) {
+	deliveries := []models.WebhookDelivery{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, delivery := range st.deliveries {
+			if query.Filter.Matches(delivery) {
+				deliveries = append(deliveries, detachDelivery(delivery))
+			}
+		}
+	})
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	total := int64(len(deliveries))
+	if query.PageSize > 0 {
+		start := min(query.Offset(), len(deliveries))
+		end := min(start+query.PageSize, len(deliveries))
+		deliveries = deliveries[start:end]
+	}
+	return deliveries, total, nil
+}
+
+// ListDueForUpdate relies on the transaction holding the store, which no
+// other dispatcher can then claim deliveries from.
+func (r *WebhookDeliveryRepository) ListDueForUpdate(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	r.store.access(r.inTx, func(st *state) {
+		for _, delivery := range st.deliveries {
+			if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+				deliveries = append(deliveries, detachDelivery(delivery))
+			}
+		}
+	})
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) Update(delivery *models.WebhookDelivery) error {
+	if delivery.ID == 0 {
+		return r.Create(delivery)
+	}
+	r.store.access(r.inTx, func(st *state) {
+		delivery.UpdatedAt = time.Now()
+		setRow(st, st.deliveries, delivery.ID, detachDelivery(*delivery))
+	})
+	return nil
+}
+
+// detachDelivery copies the payload and pointer fields so the stored
+// delivery shares no memory with the caller's.
+func detachDelivery(delivery models.WebhookDelivery) models.WebhookDelivery {
+	delivery.Payload = slices.Clone(delivery.Payload)
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		delivery.DeliveredAt = &deliveredAt
+	}
+	return delivery
+}
@@ -0,0 +1,113 @@
+package persistence
+
+import (
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+const bookFullTextIndex = "idx_books_fulltext"
+
+// Migrate brings the schema up to date: it creates or alters the tables and
+// adds the indexes GORM tags cannot express.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Author{}, &models.Subject{}, &models.Book{}, &models.Copy{}, &models.BorrowingRecord{}, &models.Fine{}, &models.Hold{}, &models.BorrowingArchive{}, &models.AuditEntry{}, &models.OutboxEvent{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}); err != nil {
+		return err
+	}
+
+	// Books used to have a single author, kept only in the byline
+	if err := db.Transaction(backfillAuthors); err != nil {
+		return err
+	}
+
+	// Books used to count their available copies in a column of their own
+	if db.Migrator().HasColumn(&models.Book{}, "availability") {
+		if err := db.Transaction(expandAvailability); err != nil {
+			return err
+		}
+		if err := db.Migrator().DropColumn(&models.Book{}, "availability"); err != nil {
+			return err
+		}
+	}
+
+	// FULLTEXT indexes are MySQL specific; other dialects search without one
+	if db.Dialector.Name() == "mysql" && !db.Migrator().HasIndex(&models.Book{}, bookFullTextIndex) {
+		if err := db.Exec("CREATE FULLTEXT INDEX " + bookFullTextIndex + " ON books (title, author, genre)").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandAvailability replaces the old availability counter with copies: one
+// available copy per unit of the counter, and one copy on loan for each
+// active loan, which is linked to it. Books that already have copies are
+// left alone.
+func expandAvailability(tx *gorm.DB) error {
+	var books []struct {
+		ID           uint
+		Availability uint
+	}
+	if err := tx.Unscoped().Table("books").Select("id, availability").Find(&books).Error; err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		var existing int64
+		if err := tx.Unscoped().Model(&models.Copy{}).Where("book_id = ?", book.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		seq := 0
+		for i := uint(0); i < book.Availability; i++ {
+			seq++
+			bookCopy := models.Copy{BookID: book.ID, Barcode: models.CopyBarcode(book.ID, seq), Status: models.CopyStatusAvailable}
+			if err := tx.Create(&bookCopy).Error; err != nil {
+				return err
+			}
+		}
+
+		var loans []models.BorrowingRecord
+		err := tx.Where("book_id = ? AND return_date IS NULL AND copy_id IS NULL", book.ID).Order("id").Find(&loans).Error
+		if err != nil {
+			return err
+		}
+		for _, loan := range loans {
+			seq++
+			bookCopy := models.Copy{BookID: book.ID, Barcode: models.CopyBarcode(book.ID, seq), Status: models.CopyStatusOnLoan}
+			if err := tx.Create(&bookCopy).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&loan).Update("copy_id", bookCopy.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backfillAuthors gives every book without linked authors one author named
+// after its byline.
+func backfillAuthors(tx *gorm.DB) error {
+	var books []models.Book
+	err := tx.Unscoped().
+		Where("author <> '' AND NOT EXISTS (SELECT 1 FROM book_authors WHERE book_authors.book_id = books.id)").
+		Find(&books).Error
+	if err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		author := models.Author{Name: book.Author}
+		if err := tx.Where("name = ?", author.Name).FirstOrCreate(&author).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO book_authors (book_id, author_id) VALUES (?, ?)", book.ID, author.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
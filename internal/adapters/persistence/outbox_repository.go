@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.OutboxRepository = (*OutboxRepository)(nil)
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{DB: db}
+}
+
+func (r *OutboxRepository) Create(event *models.OutboxEvent) error {
+	return r.DB.Create(event).Error
+}
+
+func (r *OutboxRepository) ListDueForUpdate(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *OutboxRepository) Update(event *models.OutboxEvent) error {
+	return r.DB.Save(event).Error
+}
@@ -0,0 +1,21 @@
+package persistence
+
+import "testing"
+
+func TestGetByIDReturnsNilWhenMissing(t *testing.T) {
+	db, _ := newRecordingDB(t)
+	books, borrowings := NewBookRepository(db), NewBorrowingRepository(db)
+
+	if book, err := books.GetByID(1); book != nil || err != nil {
+		t.Errorf("BookRepository.GetByID = %+v, %v; want nil, nil", book, err)
+	}
+	if book, err := books.GetByIDForUpdate(1); book != nil || err != nil {
+		t.Errorf("BookRepository.GetByIDForUpdate = %+v, %v; want nil, nil", book, err)
+	}
+	if record, err := borrowings.GetByID(1); record != nil || err != nil {
+		t.Errorf("BorrowingRepository.GetByID = %+v, %v; want nil, nil", record, err)
+	}
+	if record, err := borrowings.GetByIDForUpdate(1); record != nil || err != nil {
+		t.Errorf("BorrowingRepository.GetByIDForUpdate = %+v, %v; want nil, nil", record, err)
+	}
+}
@@ -0,0 +1,37 @@
+package persistence
+
+import (
+	"hex/internal/application/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs a unit of work against repositories that share a single
+// database transaction. The transaction is committed when fn returns nil and
+// rolled back otherwise.
+type Transactor struct {
+	DB *gorm.DB
+}
+
+var _ repositories.Transactor = (*Transactor)(nil)
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{DB: db}
+}
+
+func (t *Transactor) WithinTransaction(fn func(tx repositories.Tx) error) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(repositories.Tx{
+			Books:      NewBookRepository(tx),
+			Copies:     NewCopyRepository(tx),
+			Borrowings: NewBorrowingRepository(tx),
+			Fines:      NewFineRepository(tx),
+			Holds:      NewHoldRepository(tx),
+			Archives:   NewArchiveRepository(tx),
+			Audit:      NewAuditRepository(tx),
+			Outbox:     NewOutboxRepository(tx),
+			Webhooks:   NewWebhookRepository(tx),
+			Deliveries: NewWebhookDeliveryRepository(tx),
+		})
+	})
+}
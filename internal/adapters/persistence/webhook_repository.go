@@ -0,0 +1,138 @@
+package persistence
+
+import (
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{DB: db}
+}
+
+func (r *WebhookRepository) Create(subscription *models.WebhookSubscription) error {
+	return r.DB.Create(subscription).Error
+}
+
+func (r *WebhookRepository) GetByID(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.DB.First(&subscription, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) GetByIDForUpdate(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) List(activeOnly bool) ([]models.WebhookSubscription, error) {
+	db := r.DB
+	if activeOnly {
+		db = db.Where("active = ?", true)
+	}
+
+	var subscriptions []models.WebhookSubscription
+	err := db.Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *WebhookRepository) Update(subscription *models.WebhookSubscription) error {
+	return r.DB.Save(subscription).Error
+}
+
+func (r *WebhookRepository) Delete(id uint) error {
+	return r.DB.Delete(&models.WebhookSubscription{}, id).Error
+}
+
+type WebhookDeliveryRepository struct {
+	DB *gorm.DB
+}
+
+var _ repositories.WebhookDeliveryRepository = (*WebhookDeliveryRepository)(nil)
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{DB: db}
+}
+
+func (r *WebhookDeliveryRepository) Create(delivery *models.WebhookDelivery) error {
+	return r.DB.Create(delivery).Error
+}
+
+func (r *WebhookDeliveryRepository) GetByIDForUpdate(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) List(query repositories.WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error) {
+	filter := webhookDeliveryFilterScope(query.Filter)
+
+	var total int64
+	if err := r.DB.Model(&models.WebhookDelivery{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	db := r.DB.Scopes(filter).Order("id DESC")
+	if query.PageSize > 0 {
+		db = db.Offset(query.Offset()).Limit(query.PageSize)
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := db.Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *WebhookDeliveryRepository) ListDueForUpdate(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.DB.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *WebhookDeliveryRepository) Update(delivery *models.WebhookDelivery) error {
+	return r.DB.Save(delivery).Error
+}
+
+func webhookDeliveryFilterScope(filter repositories.WebhookDeliveryFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.SubscriptionID != 0 {
+			db = db.Where("subscription_id = ?", filter.SubscriptionID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		return db
+	}
+}
@@ -2,6 +2,8 @@ package seeder
 
 import (
 	"fmt"
+	"hex/internal/adapters/persistence"
+	"hex/internal/application/repositories"
 	"hex/pkg/models"
 	"math/rand"
 	"time"
@@ -11,26 +13,65 @@ import (
 )
 
 func Seed(db *gorm.DB) error {
-	// Delete existing books and borrowing records
-	db.Migrator().DropTable(&models.BorrowingRecord{}, &models.Book{})
-	db.AutoMigrate(&models.Book{}, &models.BorrowingRecord{})
+	// Delete existing books and everything that refers to them, including
+	// the links to authors and subjects that would otherwise attach to the
+	// new books reusing their IDs
+	err := db.Migrator().DropTable(&models.WebhookDelivery{}, &models.WebhookSubscription{}, &models.OutboxEvent{}, &models.AuditEntry{}, &models.BorrowingArchive{}, &models.Hold{}, &models.Fine{}, &models.BorrowingRecord{}, &models.Copy{}, "book_authors", "book_subjects", &models.Author{}, &models.Subject{}, &models.Book{})
+	if err != nil {
+		return fmt.Errorf("failed to drop tables: %w", err)
+	}
+	if err := persistence.Migrate(db); err != nil {
+		return err
+	}
+
+	// Create 10 random book records, each with its authors and copies
+	return SeedRepository(persistence.NewBookRepository(db), persistence.NewCopyRepository(db))
+}
+
+// SeedRepository fills freshly created repositories, such as the in-memory
+// ones, with random books, their authors and their copies. Seed uses it on
+// the tables it has just recreated.
+func SeedRepository(books repositories.BookRepository, copies repositories.CopyRepository) error {
+	for _, book := range generateBooks(10) {
+		if err := books.Create(&book); err != nil {
+			return err
+		}
+		for _, bookCopy := range generateCopies(book.ID, seedCopies) {
+			if err := copies.Create(&bookCopy); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seedCopies is how many copies of each seeded book are on the shelf.
+const seedCopies = 10
 
-	// Create 10 random book records
-	books := make([]models.Book, 10)
-	for i := 0; i < 10; i++ {
+func generateBooks(n int) []models.Book {
+	books := make([]models.Book, n)
+	for i := 0; i < n; i++ {
 		books[i] = models.Book{
 			Title:           "Book " + fmt.Sprint(i+1),
 			Author:          "Author " + fmt.Sprint(i+1),
+			Authors:         []models.Author{{Name: "Author " + fmt.Sprint(i+1)}},
 			PublicationDate: datatypes.Date(generateRandomPublicationDate()),
 			Genre:           generateRandomGenre(),
-			Availability:    10,
 		}
 	}
-	if err := db.Create(&books).Error; err != nil {
-		return err
-	}
+	return books
+}
 
-	return nil
+func generateCopies(bookID uint, n int) []models.Copy {
+	copies := make([]models.Copy, n)
+	for i := 0; i < n; i++ {
+		copies[i] = models.Copy{
+			BookID:  bookID,
+			Barcode: models.CopyBarcode(bookID, i+1),
+			Status:  models.CopyStatusAvailable,
+		}
+	}
+	return copies
 }
 
 func generateRandomPublicationDate() time.Time {
@@ -1,5 +1,54 @@
 package auth
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type AuthService interface {
 	Authenticate(token string) (userID string, role string, err error)
 }
+
+// Role is what a user is allowed to do in the library.
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleLibrarian Role = "librarian"
+	RoleMember    Role = "member"
+)
+
+// Principal is the authenticated user behind a request.
+type Principal struct {
+	UserID uint
+	Role   Role
+}
+
+// NewPrincipal turns the raw values returned by an AuthService into a
+// Principal, rejecting user IDs that are not numeric and unknown roles.
+func NewPrincipal(userID string, role string) (Principal, error) {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid user ID %q", userID)
+	}
+
+	r := Role(strings.ToLower(role))
+	switch r {
+	case RoleAdmin, RoleLibrarian, RoleMember:
+	default:
+		return Principal{}, fmt.Errorf("unknown role %q", role)
+	}
+
+	return Principal{UserID: uint(id), Role: r}, nil
+}
+
+// HasRole reports whether the principal holds one of roles.
+func (p Principal) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"hex/pkg/models"
+)
+
+// Message is a domain event as other systems receive it.
+type Message struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewMessage wraps an event from the outbox for publishing.
+func NewMessage(event models.OutboxEvent) Message {
+	return Message{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Data:       json.RawMessage(event.Payload),
+	}
+}
+
+// Publisher is the port through which domain events leave the application.
+// Publish returns nil only once the message has been accepted; otherwise it
+// is retried later. A message may therefore arrive more than once, and
+// consumers should ignore IDs they have already seen.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
@@ -0,0 +1,21 @@
+package events
+
+import "context"
+
+// WebhookRequest is one attempt at delivering an event to a webhook
+// subscription. Body is sent as is and signed with Secret.
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	DeliveryID uint
+	EventType  string
+	Body       []byte
+}
+
+// WebhookSender is the port through which webhook deliveries are sent. Send
+// returns the status code the subscriber answered with, or 0 when it did not
+// answer. The attempt failed when the error is not nil, which includes any
+// answer outside 2xx.
+type WebhookSender interface {
+	Send(ctx context.Context, request WebhookRequest) (int, error)
+}
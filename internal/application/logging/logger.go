@@ -0,0 +1,26 @@
+package logging
+
+// Log levels understood by every Logger adapter.
+const (
+	LevelDebug = "DEBUG"
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
+// Field is a structured key/value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F builds a Field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger is the port the application uses to record what it does. Adapters
+// decide where entries go; Log must never block the caller on failure.
+type Logger interface {
+	Log(level string, message string, fields ...Field)
+}
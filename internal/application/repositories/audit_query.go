@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"hex/pkg/models"
+)
+
+// AuditFilter narrows a listing of audit entries. Zero values do not filter.
+type AuditFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorID    uint
+	Action     string
+	// From and To bound when the change was made; From is inclusive and To
+	// exclusive.
+	From *time.Time
+	To   *time.Time
+}
+
+// Matches reports whether entry passes the filter.
+func (f AuditFilter) Matches(entry models.AuditEntry) bool {
+	if f.EntityType != "" && entry.EntityType != f.EntityType {
+		return false
+	}
+	if f.EntityID != 0 && entry.EntityID != f.EntityID {
+		return false
+	}
+	if f.ActorID != 0 && entry.ActorID != f.ActorID {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+	if f.From != nil && entry.CreatedAt.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !entry.CreatedAt.Before(*f.To) {
+		return false
+	}
+	return true
+}
+
+// AuditQuery selects one page of audit entries, newest first. Page starts
+// at 1.
+type AuditQuery struct {
+	Filter   AuditFilter
+	Page     int
+	PageSize int
+}
+
+// Offset is the number of matching entries before the requested page.
+func (q AuditQuery) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
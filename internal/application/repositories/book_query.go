@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"hex/pkg/models"
+)
+
+// Fields books can be sorted by.
+const (
+	BookSortTitle           = "title"
+	BookSortAuthor          = "author"
+	BookSortGenre           = "genre"
+	BookSortPublicationDate = "publication_date"
+	BookSortAvailability    = "availability"
+	BookSortCreatedAt       = "created_at"
+)
+
+// BookSortFields lists every field accepted in BookSort.Field.
+var BookSortFields = []string{
+	BookSortTitle,
+	BookSortAuthor,
+	BookSortGenre,
+	BookSortPublicationDate,
+	BookSortAvailability,
+	BookSortCreatedAt,
+}
+
+// BookFilter narrows a book listing. Zero values do not filter.
+type BookFilter struct {
+	// Genre matches the whole genre, ignoring case.
+	Genre string
+	// Author matches any part of the author name, ignoring case.
+	Author string
+	// Available keeps only books with (true) or without (false) copies left.
+	Available *bool
+	// PublishedFrom and PublishedTo bound the publication date, inclusive.
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	// ISBN matches exactly and must already be normalized to 13 digits.
+	ISBN string
+	// Publisher and Description match any part of the field, ignoring case.
+	Publisher   string
+	Description string
+	// Edition, Language and Subject match the whole value, ignoring case.
+	// Subject matches when it is any one of the book's subjects.
+	Edition  string
+	Language string
+	Subject  string
+	// MinPages and MaxPages bound the page count, inclusive.
+	MinPages *uint
+	MaxPages *uint
+	// HasCover keeps only books with (true) or without (false) a cover URL.
+	HasCover *bool
+	// IncludeDeleted lists deleted books alongside the others.
+	IncludeDeleted bool
+}
+
+// BookSort orders a listing by one field.
+type BookSort struct {
+	Field string
+	Desc  bool
+}
+
+// BookQuery selects one page of books. Page starts at 1. Results are always
+// ordered by ID after the requested sort so pages are stable.
+type BookQuery struct {
+	Filter   BookFilter
+	Sort     []BookSort
+	Page     int
+	PageSize int
+}
+
+// Offset is the number of matching books before the requested page.
+func (q BookQuery) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// Matches reports whether book passes the filter. Adapters that cannot push
+// filtering down to a database use it directly.
+func (f BookFilter) Matches(book models.Book) bool {
+	if f.Genre != "" && !strings.EqualFold(book.Genre, f.Genre) {
+		return false
+	}
+	if f.Author != "" && !containsFold(book.Author, f.Author) {
+		return false
+	}
+	if f.Available != nil && (book.Availability > 0) != *f.Available {
+		return false
+	}
+	published := time.Time(book.PublicationDate)
+	if f.PublishedFrom != nil && published.Before(*f.PublishedFrom) {
+		return false
+	}
+	if f.PublishedTo != nil && published.After(*f.PublishedTo) {
+		return false
+	}
+	if f.ISBN != "" && (book.ISBN == nil || *book.ISBN != f.ISBN) {
+		return false
+	}
+	if f.Publisher != "" && !containsFold(book.Publisher, f.Publisher) {
+		return false
+	}
+	if f.Description != "" && !containsFold(book.Description, f.Description) {
+		return false
+	}
+	if f.Edition != "" && !strings.EqualFold(book.Edition, f.Edition) {
+		return false
+	}
+	if f.Language != "" && !strings.EqualFold(book.Language, f.Language) {
+		return false
+	}
+	if f.Subject != "" && !slices.ContainsFunc(book.Subjects, func(subject models.Subject) bool {
+		return strings.EqualFold(subject.Name, f.Subject)
+	}) {
+		return false
+	}
+	if f.MinPages != nil && book.PageCount < *f.MinPages {
+		return false
+	}
+	if f.MaxPages != nil && book.PageCount > *f.MaxPages {
+		return false
+	}
+	if f.HasCover != nil && (book.CoverURL != "") != *f.HasCover {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// BookMatch is a search hit. Scores are only comparable within one search.
+type BookMatch struct {
+	Book  models.Book `json:"book"`
+	Score float64     `json:"score"`
+}
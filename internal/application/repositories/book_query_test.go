@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"hex/pkg/models"
+
+	"gorm.io/datatypes"
+)
+
+func TestBookFilterMatches(t *testing.T) {
+	book := models.Book{
+		Title:           "The Tombs of Atuan",
+		Author:          "Ursula K. Le Guin",
+		Genre:           "Fantasy",
+		PublicationDate: datatypes.Date(time.Date(1970, 12, 1, 0, 0, 0, 0, time.UTC)),
+		Availability:    2,
+	}
+	yes, no := true, false
+	from, to := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1970, 12, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter BookFilter
+		want   bool
+	}{
+		{"no filter", BookFilter{}, true},
+		{"genre ignoring case", BookFilter{Genre: "fantasy"}, true},
+		{"other genre", BookFilter{Genre: "Fan"}, false},
+		{"part of author", BookFilter{Author: "le guin"}, true},
+		{"available", BookFilter{Available: &yes}, true},
+		{"unavailable", BookFilter{Available: &no}, false},
+		{"published within range, inclusive", BookFilter{PublishedFrom: &from, PublishedTo: &to}, true},
+		{"published before range", BookFilter{PublishedFrom: &later}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(book); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"hex/pkg/models"
+)
+
+// Borrowing statuses accepted by BorrowingFilter.Status.
+const (
+	BorrowingStatusActive   = "active"
+	BorrowingStatusOverdue  = "overdue"
+	BorrowingStatusReturned = "returned"
+)
+
+// BorrowingStatuses lists every accepted BorrowingFilter.Status.
+var BorrowingStatuses = []string{BorrowingStatusActive, BorrowingStatusOverdue, BorrowingStatusReturned}
+
+// BorrowingFilter narrows a listing of borrowing records. Zero values do not
+// filter. Active loans include overdue ones.
+type BorrowingFilter struct {
+	MemberID uint
+	BookID   uint
+	Status   string
+	Overdue  *bool
+	// Now is the reference time for overdue checks.
+	Now time.Time
+}
+
+// Matches reports whether record passes the filter. Adapters that cannot push
+// filtering down to a database use it directly.
+func (f BorrowingFilter) Matches(record models.BorrowingRecord) bool {
+	if f.MemberID != 0 && record.MemberID != f.MemberID {
+		return false
+	}
+	if f.BookID != 0 && record.BookID != f.BookID {
+		return false
+	}
+
+	overdue := record.IsOverdue(f.Now)
+	switch f.Status {
+	case BorrowingStatusActive:
+		if record.IsReturned() {
+			return false
+		}
+	case BorrowingStatusOverdue:
+		if !overdue {
+			return false
+		}
+	case BorrowingStatusReturned:
+		if !record.IsReturned() {
+			return false
+		}
+	}
+	if f.Overdue != nil && overdue != *f.Overdue {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"strings"
+
+	"hex/pkg/models"
+)
+
+// CopyFilter narrows a listing of copies. Zero values do not filter.
+type CopyFilter struct {
+	BookID  uint
+	Status  string
+	Barcode string
+}
+
+// Matches reports whether copy passes the filter. Barcodes match ignoring
+// case.
+func (f CopyFilter) Matches(copy models.Copy) bool {
+	if f.BookID != 0 && copy.BookID != f.BookID {
+		return false
+	}
+	if f.Status != "" && copy.Status != f.Status {
+		return false
+	}
+	if f.Barcode != "" && !strings.EqualFold(copy.Barcode, f.Barcode) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import "hex/pkg/models"
+
+// FineFilter narrows a listing of fines. Zero values do not filter.
+type FineFilter struct {
+	MemberID          uint
+	BorrowingRecordID uint
+	Status            string
+}
+
+// Matches reports whether fine passes the filter.
+func (f FineFilter) Matches(fine models.Fine) bool {
+	if f.MemberID != 0 && fine.MemberID != f.MemberID {
+		return false
+	}
+	if f.BorrowingRecordID != 0 && fine.BorrowingRecordID != f.BorrowingRecordID {
+		return false
+	}
+	if f.Status != "" && fine.Status != f.Status {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"slices"
+
+	"hex/pkg/models"
+)
+
+// HoldFilter narrows a listing of holds. Zero values do not filter.
+type HoldFilter struct {
+	BookID   uint
+	MemberID uint
+	Statuses []string
+}
+
+// Matches reports whether hold passes the filter.
+func (f HoldFilter) Matches(hold models.Hold) bool {
+	if f.BookID != 0 && hold.BookID != f.BookID {
+		return false
+	}
+	if f.MemberID != 0 && hold.MemberID != f.MemberID {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, hold.Status) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hex/pkg/models"
+)
+
+// ErrDuplicate is returned, possibly wrapped, by Create and Update when the
+// row would repeat a value that must be unique, such as a book's ISBN or a
+// copy's barcode. Services check first, but a concurrent transaction can
+// take the value in between.
+var ErrDuplicate = errors.New("duplicate value for a unique field")
+
+// BookRepository is the port through which the application reads and
+// writes books. GetByID and GetByIDForUpdate return nil, nil when the book
+// does not exist. Books are returned with Availability counted from their
+// copies and with Authors and Subjects loaded.
+type BookRepository interface {
+	Create(book *models.Book) error
+	GetAll() ([]models.Book, error)
+	// List returns one page of books matching query along with the total
+	// number of matches across all pages.
+	List(query BookQuery) ([]models.Book, int64, error)
+	// EachBatch calls fn with successive batches of at most size books
+	// matching filter, in ID order, so that callers can walk the whole
+	// catalog without loading it at once. It stops at the first error fn
+	// returns.
+	EachBatch(filter BookFilter, size int, fn func(books []models.Book) error) error
+	// Search matches text against title, author and genre and returns at
+	// most limit books, most relevant first.
+	Search(text string, limit int) ([]BookMatch, error)
+	GetByID(id uint) (*models.Book, error)
+	GetByIDForUpdate(id uint) (*models.Book, error)
+	// GetByIDIncludingDeleted is GetByID for books that may be deleted.
+	GetByIDIncludingDeleted(id uint) (*models.Book, error)
+	// GetByISBN finds the book with a normalized ISBN, including deleted
+	// books, which keep their ISBN.
+	GetByISBN(isbn string) (*models.Book, error)
+	// GetByTitleAndAuthor finds the first book whose title and byline match
+	// exactly, ignoring case.
+	GetByTitleAndAuthor(title, author string) (*models.Book, error)
+	// Create and Update save Authors and Subjects by name, reusing existing
+	// ones, and replace the book's previous ones.
+	Update(book *models.Book) error
+	// Delete marks a book deleted; Restore brings it back.
+	Delete(id uint) error
+	Restore(id uint) error
+	// Purge permanently removes a book together with its copies, holds,
+	// borrowing records and their fines. Callers archive what they need
+	// first.
+	Purge(id uint) error
+}
+
+// CopyRepository is the port through which the application reads and writes
+// the physical copies of books.
+type CopyRepository interface {
+	Create(copy *models.Copy) error
+	GetByID(id uint) (*models.Copy, error)
+	GetByIDForUpdate(id uint) (*models.Copy, error)
+	List(filter CopyFilter) ([]models.Copy, error)
+	Update(copy *models.Copy) error
+	// NextAvailableForUpdate locks and returns the available copy of a book
+	// with the lowest ID, or nil, nil when none is on the shelf.
+	NextAvailableForUpdate(bookID uint) (*models.Copy, error)
+	// CountForBook counts every copy ever added to a book, including
+	// withdrawn ones.
+	CountForBook(bookID uint) (int64, error)
+}
+
+// BorrowingRepository is the port through which the application reads and
+// writes borrowing records. Records are returned with their Book and Copy
+// loaded.
+type BorrowingRepository interface {
+	Create(borrowingRecord *models.BorrowingRecord) error
+	GetByID(id uint) (*models.BorrowingRecord, error)
+	GetByIDForUpdate(id uint) (*models.BorrowingRecord, error)
+	List(filter BorrowingFilter) ([]models.BorrowingRecord, error)
+	// EachBatch is List in batches of at most size records; see
+	// BookRepository.EachBatch.
+	EachBatch(filter BorrowingFilter, size int, fn func(records []models.BorrowingRecord) error) error
+	Update(borrowingRecord *models.BorrowingRecord) error
+}
+
+// FineRepository is the port through which the application reads and
+// writes fines. Fines are returned with their BorrowingRecord and its Book
+// loaded.
+type FineRepository interface {
+	Create(fine *models.Fine) error
+	GetByID(id uint) (*models.Fine, error)
+	GetByIDForUpdate(id uint) (*models.Fine, error)
+	List(filter FineFilter) ([]models.Fine, error)
+	Update(fine *models.Fine) error
+	// OutstandingBalance sums what a member still owes across unpaid fines.
+	OutstandingBalance(memberID uint) (int64, error)
+}
+
+// HoldRepository is the port through which the application reads and
+// writes holds. List returns holds in queue order, with their Book loaded.
+type HoldRepository interface {
+	Create(hold *models.Hold) error
+	GetByID(id uint) (*models.Hold, error)
+	GetByIDForUpdate(id uint) (*models.Hold, error)
+	List(filter HoldFilter) ([]models.Hold, error)
+	Update(hold *models.Hold) error
+}
+
+// ArchiveRepository is the port through which the application keeps the
+// borrowing history of purged books.
+type ArchiveRepository interface {
+	Create(archive *models.BorrowingArchive) error
+}
+
+// AuditRepository is the port through which the application appends to and
+// reads the audit log. Entries cannot be changed once created.
+type AuditRepository interface {
+	Create(entry *models.AuditEntry) error
+	// List returns one page of entries matching query, newest first, along
+	// with the total number of matches across all pages.
+	List(query AuditQuery) ([]models.AuditEntry, int64, error)
+}
+
+// OutboxRepository is the port through which the application queues domain
+// events and the dispatcher relays them.
+type OutboxRepository interface {
+	Create(event *models.OutboxEvent) error
+	// ListDueForUpdate locks and returns at most limit unpublished events
+	// whose next attempt is due by now, oldest first. Events another
+	// transaction has locked are skipped rather than waited for.
+	ListDueForUpdate(now time.Time, limit int) ([]models.OutboxEvent, error)
+	Update(event *models.OutboxEvent) error
+}
+
+// WebhookRepository is the port through which the application reads and
+// writes webhook subscriptions. GetByID and GetByIDForUpdate return nil, nil
+// when the subscription does not exist.
+type WebhookRepository interface {
+	Create(subscription *models.WebhookSubscription) error
+	GetByID(id uint) (*models.WebhookSubscription, error)
+	GetByIDForUpdate(id uint) (*models.WebhookSubscription, error)
+	// List returns subscriptions in ID order, only the active ones when
+	// activeOnly is set.
+	List(activeOnly bool) ([]models.WebhookSubscription, error)
+	Update(subscription *models.WebhookSubscription) error
+	Delete(id uint) error
+}
+
+// WebhookDeliveryRepository is the port through which the application queues
+// webhook deliveries and keeps their log.
+type WebhookDeliveryRepository interface {
+	Create(delivery *models.WebhookDelivery) error
+	GetByIDForUpdate(id uint) (*models.WebhookDelivery, error)
+	// List returns one page of deliveries matching query, newest first, along
+	// with the total number of matches across all pages.
+	List(query WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error)
+	// ListDueForUpdate locks and returns at most limit pending deliveries
+	// whose next attempt is due by now, oldest first. Deliveries another
+	// transaction has locked are skipped rather than waited for.
+	ListDueForUpdate(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	Update(delivery *models.WebhookDelivery) error
+}
+
+// Tx holds the repositories bound to a single transaction.
+type Tx struct {
+	Books      BookRepository
+	Copies     CopyRepository
+	Borrowings BorrowingRepository
+	Fines      FineRepository
+	Holds      HoldRepository
+	Archives   ArchiveRepository
+	Audit      AuditRepository
+	Outbox     OutboxRepository
+	Webhooks   WebhookRepository
+	Deliveries WebhookDeliveryRepository
+}
+
+// Transactor runs fn inside a transaction. Changes made through tx are
+// committed when fn returns nil and rolled back otherwise.
+type Transactor interface {
+	WithinTransaction(fn func(tx Tx) error) error
+}
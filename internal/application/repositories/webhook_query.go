@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"hex/pkg/models"
+)
+
+// WebhookDeliveryFilter narrows a listing of webhook deliveries. Zero values
+// do not filter.
+type WebhookDeliveryFilter struct {
+	SubscriptionID uint
+	Status         string
+}
+
+// Matches reports whether delivery passes the filter.
+func (f WebhookDeliveryFilter) Matches(delivery models.WebhookDelivery) bool {
+	if f.SubscriptionID != 0 && delivery.SubscriptionID != f.SubscriptionID {
+		return false
+	}
+	if f.Status != "" && delivery.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// WebhookDeliveryQuery selects one page of webhook deliveries, newest first.
+// Page starts at 1.
+type WebhookDeliveryQuery struct {
+	Filter   WebhookDeliveryFilter
+	Page     int
+	PageSize int
+}
+
+// Offset is the number of matching deliveries before the requested page.
+func (q WebhookDeliveryQuery) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
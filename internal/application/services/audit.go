@@ -0,0 +1,127 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	appauth "hex/internal/application/auth"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// Actor is who a change is made by, and in which request, as recorded in the
+// audit log.
+type Actor struct {
+	UserID    uint
+	Role      appauth.Role
+	RequestID string
+}
+
+type AuditService interface {
+	// ListEntries returns one page of the audit log, newest first, and the
+	// total number of matching entries.
+	ListEntries(query repositories.AuditQuery) ([]models.AuditEntry, int64, error)
+}
+
+type auditService struct {
+	auditRepo repositories.AuditRepository
+	logger    logging.Logger
+}
+
+func NewAuditService(auditRepo repositories.AuditRepository, logger logging.Logger) AuditService {
+	return &auditService{auditRepo: auditRepo, logger: logger}
+}
+
+func (s *auditService) ListEntries(query repositories.AuditQuery) ([]models.AuditEntry, int64, error) {
+	entries, total, err := s.auditRepo.List(query)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get audit entries: "+err.Error())
+		return nil, 0, err
+	}
+	s.logger.Log("INFO", "Retrieved audit entries", logging.F("count", len(entries)), logging.F("total", total), logging.F("page", query.Page))
+	return entries, total, nil
+}
+
+// auditSnapshot is an entity's state as the audit log compares it: its
+// fields as they are encoded to JSON.
+type auditSnapshot map[string]any
+
+// auditIgnored are fields left out of snapshots: timestamps every save
+// touches, values computed on read, and the related entities loaded
+// alongside, which are audited on their own.
+var auditIgnored = []string{"CreatedAt", "UpdatedAt", "Overdue", "Position", "Book", "Copy", "BorrowingRecord"}
+
+// snapshot captures entity for the audit log. A change that edits an
+// entity in place must snapshot it before editing it. A nil entity, one that
+// does not exist, has a nil snapshot.
+func snapshot(entity any) (auditSnapshot, error) {
+	if taken, ok := entity.(auditSnapshot); ok || entity == nil {
+		return taken, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %T: %w", entity, err)
+	}
+	var fields auditSnapshot
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to snapshot %T: %w", entity, err)
+	}
+	for _, name := range auditIgnored {
+		delete(fields, name)
+	}
+	return fields, nil
+}
+
+// auditDiff lists the fields that differ between before and after, either
+// of which is nil when the entity did not exist on that side of the change.
+func auditDiff(before, after auditSnapshot) map[string]map[string]any {
+	diff := map[string]map[string]any{}
+	for name, value := range before {
+		if other, ok := after[name]; !ok || !reflect.DeepEqual(value, other) {
+			diff[name] = map[string]any{"before": value}
+		}
+	}
+	for name, value := range after {
+		if old, ok := before[name]; !ok || !reflect.DeepEqual(old, value) {
+			if diff[name] == nil {
+				diff[name] = map[string]any{}
+			}
+			diff[name]["after"] = value
+		}
+	}
+	return diff
+}
+
+// recordAudit appends an entry for a change actor made to an entity, within
+// the transaction making the change so that both are saved or neither is.
+// before and after are the entity, or a snapshot of it, on either side of
+// the change; nil on the side where it does not exist.
+func recordAudit(tx repositories.Tx, actor Actor, action, entityType string, entityID uint, before, after any) error {
+	beforeFields, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterFields, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(auditDiff(beforeFields, afterFields))
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	entry := models.AuditEntry{
+		ActorID:    actor.UserID,
+		ActorRole:  string(actor.Role),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  actor.RequestID,
+	}
+	if err := tx.Audit.Create(&entry); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	appauth "hex/internal/application/auth"
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+func TestBookChangesAreAudited(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	audit := services.NewAuditService(ts.audit, nopLogger{})
+	librarian := services.Actor{UserID: 3, Role: appauth.RoleLibrarian, RequestID: "req-1"}
+
+	book := newTestBook("The Lathe of Heaven", 1)
+	if err := books.CreateBook(book, librarian); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	_, err := books.UpdateBook(idOf(book), 0, librarian, func(book *models.Book) error {
+		book.Title = "The Lathe of Heaven (Revised)"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBook: %v", err)
+	}
+
+	entries, total, err := audit.ListEntries(repositories.AuditQuery{
+		Filter:   repositories.AuditFilter{EntityType: models.AuditEntityBook, EntityID: book.ID},
+		Page:     1,
+		PageSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	if total != 2 || len(entries) != 2 {
+		t.Fatalf("ListEntries = %d of %d entries, want 2", len(entries), total)
+	}
+	update, create := entries[0], entries[1]
+	if create.Action != models.AuditActionCreate || update.Action != models.AuditActionUpdate {
+		t.Errorf("actions %q, %q; want update then create", update.Action, create.Action)
+	}
+	if update.ActorID != 3 || update.ActorRole != "librarian" || update.RequestID != "req-1" {
+		t.Errorf("update entry by %d (%s) in %q, want the librarian in req-1", update.ActorID, update.ActorRole, update.RequestID)
+	}
+
+	var changes map[string]map[string]any
+	if err := json.Unmarshal(update.Changes, &changes); err != nil {
+		t.Fatalf("changes %s: %v", update.Changes, err)
+	}
+	title := changes["Title"]
+	if title["before"] != "The Lathe of Heaven" || title["after"] != "The Lathe of Heaven (Revised)" {
+		t.Errorf("Title change = %v", title)
+	}
+	for field := range changes {
+		if field != "Title" && field != "Version" {
+			t.Errorf("update recorded an unchanged field %s: %v", field, changes[field])
+		}
+	}
+}
+
+func TestListAuditEntriesFilters(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	audit := services.NewAuditService(ts.audit, nopLogger{})
+	for i, title := range []string{"The Dispossessed", "Always Coming Home", "Lavinia"} {
+		if err := books.CreateBook(newTestBook(title, 1), services.Actor{UserID: uint(i + 1)}); err != nil {
+			t.Fatalf("CreateBook: %v", err)
+		}
+	}
+
+	entries, total, err := audit.ListEntries(repositories.AuditQuery{
+		Filter:   repositories.AuditFilter{EntityType: models.AuditEntityBook, ActorID: 2},
+		Page:     1,
+		PageSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	if total != 1 || len(entries) != 1 || entries[0].ActorID != 2 {
+		t.Errorf("ListEntries by actor 2 = %+v (total %d), want the one book they created", entries, total)
+	}
+
+	entries, total, err = audit.ListEntries(repositories.AuditQuery{
+		Filter:   repositories.AuditFilter{Action: models.AuditActionCreate},
+		Page:     2,
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("ListEntries page 2: %v", err)
+	}
+	if total != 3 || len(entries) != 1 {
+		t.Errorf("second page of 2 = %d of %d entries, want 1 of 3", len(entries), total)
+	}
+}
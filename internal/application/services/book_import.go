@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// importBatchSize is how many rows share one transaction. A batch that fails
+// for reasons other than bad input is rolled back on its own; earlier
+// batches stay saved.
+const importBatchSize = 100
+
+// Import row statuses.
+const (
+	ImportCreated  = "created"
+	ImportUpdated  = "updated"
+	ImportRejected = "rejected"
+)
+
+// ImportRow is one book read from an import file. Apply copies the fields
+// the row provides onto a book: an empty one for new books, or the existing
+// book the row matches, whose other fields are left alone. Copies is how
+// many copies a new book starts with; nil means one.
+type ImportRow struct {
+	Line   int
+	Apply  func(book *models.Book) error
+	Copies *uint
+}
+
+// ImportResult reports what happened to one row.
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	BookID uint   `json:"book_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportReport summarizes an import. In a dry run nothing is saved and new
+// books have no ID yet.
+type ImportReport struct {
+	DryRun   bool           `json:"dry_run"`
+	Created  int            `json:"created"`
+	Updated  int            `json:"updated"`
+	Rejected int            `json:"rejected"`
+	Rows     []ImportResult `json:"rows"`
+}
+
+// errDryRun rolls back a batch that was only being tried out.
+var errDryRun = errors.New("dry run")
+
+// ImportBooks creates or updates a book for each row, validating it like
+// CreateBook. A row matches an existing book by ISBN or, failing that, by
+// title and author; rows repeating an earlier row of the same import are
+// rejected. Each saved row is audited as a create or update by actor.
+func (s *BookService) ImportBooks(rows []ImportRow, dryRun bool, actor Actor) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Rows: make([]ImportResult, 0, len(rows))}
+	seen := map[string]int{}
+
+	for start := 0; start < len(rows); start += importBatchSize {
+		batch := rows[start:min(start+importBatchSize, len(rows))]
+
+		// Keys of this batch join seen only once the batch is saved, so rows
+		// of a batch that failed are not later rejected as duplicates of it
+		var results []ImportResult
+		var batchSeen map[string]int
+		err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+			results = make([]ImportResult, 0, len(batch))
+			batchSeen = map[string]int{}
+			for _, row := range batch {
+				result, err := importRow(tx, row, seen, batchSeen, actor, s.now())
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			}
+			if dryRun {
+				return errDryRun
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errDryRun) {
+			s.logger.Log("ERROR", "Failed to import batch: "+err.Error(), logging.F("first_line", batch[0].Line))
+			results = results[:0]
+			for _, row := range batch {
+				results = append(results, ImportResult{Line: row.Line, Status: ImportRejected, Reason: "not saved: the batch it was in failed"})
+			}
+		} else {
+			maps.Copy(seen, batchSeen)
+		}
+
+		for _, result := range results {
+			if dryRun && result.Status == ImportCreated {
+				result.BookID = 0
+			}
+			switch result.Status {
+			case ImportCreated:
+				report.Created++
+			case ImportUpdated:
+				report.Updated++
+			case ImportRejected:
+				report.Rejected++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+	}
+
+	s.logger.Log("INFO", "Imported books",
+		logging.F("created", report.Created), logging.F("updated", report.Updated),
+		logging.F("rejected", report.Rejected), logging.F("dry_run", dryRun))
+	return report, nil
+}
+
+// importRow saves one row. Bad input rejects the row in the result; only
+// failures that should abort the batch are returned as errors. seen maps the
+// dedup keys of rows in earlier batches to their lines, and batchSeen those
+// of earlier rows in this batch; the row's own keys are added to batchSeen.
+func importRow(tx repositories.Tx, row ImportRow, seen, batchSeen map[string]int, actor Actor, now time.Time) (ImportResult, error) {
+	reject := func(err error) (ImportResult, error) {
+		return ImportResult{Line: row.Line, Status: ImportRejected, Reason: err.Error()}, nil
+	}
+
+	book := &models.Book{}
+	if err := row.Apply(book); err != nil {
+		return reject(err)
+	}
+	existing, err := findImportMatch(tx, book)
+	if err != nil {
+		var validation *ValidationError
+		if errors.As(err, &validation) {
+			return reject(err)
+		}
+		return ImportResult{}, err
+	}
+
+	status := ImportCreated
+	var before auditSnapshot
+	if existing != nil {
+		if existing.DeletedAt.Valid {
+			return reject(fmt.Errorf("ISBN belongs to deleted book %d", existing.ID))
+		}
+		if before, err = snapshot(existing); err != nil {
+			return ImportResult{}, err
+		}
+		book, status = existing, ImportUpdated
+		if err := row.Apply(book); err != nil {
+			return reject(err)
+		}
+	}
+	if err := normalizeBook(book); err != nil {
+		return reject(err)
+	}
+
+	keys := importKeys(book)
+	for _, key := range keys {
+		if line, ok := seen[key]; ok {
+			return reject(fmt.Errorf("duplicate of line %d", line))
+		}
+		if line, ok := batchSeen[key]; ok {
+			return reject(fmt.Errorf("duplicate of line %d", line))
+		}
+	}
+	for _, key := range keys {
+		batchSeen[key] = row.Line
+	}
+
+	if err := checkISBNUnique(tx, book); err != nil {
+		if errors.Is(err, ErrDuplicateISBN) {
+			return reject(err)
+		}
+		return ImportResult{}, err
+	}
+
+	if status == ImportUpdated {
+		book.Version++
+		if err := tx.Books.Update(book); err != nil {
+			if err := duplicateISBN(err, book); errors.Is(err, ErrDuplicateISBN) {
+				return reject(err)
+			}
+			return ImportResult{}, err
+		}
+		if err := recordAudit(tx, actor, models.AuditActionUpdate, models.AuditEntityBook, book.ID, before, book); err != nil {
+			return ImportResult{}, err
+		}
+		return ImportResult{Line: row.Line, Status: status, BookID: book.ID}, nil
+	}
+
+	copies := uint(1)
+	if row.Copies != nil {
+		copies = *row.Copies
+	}
+	if err := validateNewCopies(copies); err != nil {
+		return reject(err)
+	}
+
+	book.Version = 1
+	if err := tx.Books.Create(book); err != nil {
+		if err := duplicateISBN(err, book); errors.Is(err, ErrDuplicateISBN) {
+			return reject(err)
+		}
+		return ImportResult{}, err
+	}
+	book.Availability = copies
+	for seq := 1; seq <= int(book.Availability); seq++ {
+		bookCopy := models.Copy{BookID: book.ID, Barcode: models.CopyBarcode(book.ID, seq)}
+		if err := tx.Copies.Create(&bookCopy); err != nil {
+			return ImportResult{}, err
+		}
+	}
+	if err := recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityBook, book.ID, nil, book); err != nil {
+		return ImportResult{}, err
+	}
+	if err := recordEvent(tx, models.EventBookCreated, book.ID, newBookEventData(book), now); err != nil {
+		return ImportResult{}, err
+	}
+	return ImportResult{Line: row.Line, Status: status, BookID: book.ID}, nil
+}
+
+// importKeys are the keys a book is deduplicated on within one import.
+func importKeys(book *models.Book) []string {
+	keys := []string{"title:" + strings.ToLower(book.Title) + "\x00" + strings.ToLower(book.Author)}
+	if book.ISBN != nil {
+		keys = append(keys, "isbn:"+*book.ISBN)
+	}
+	return keys
+}
+
+// findImportMatch finds the book a row stands for: the book with its ISBN,
+// even a deleted one, or else a book with the same title and author whose
+// ISBN does not contradict it. book holds only what the row provides, so it
+// has not been validated yet.
+func findImportMatch(tx repositories.Tx, book *models.Book) (*models.Book, error) {
+	var isbn string
+	if book.ISBN != nil && strings.TrimSpace(*book.ISBN) != "" {
+		var err error
+		if isbn, err = models.NormalizeISBN(*book.ISBN); err != nil {
+			return nil, &ValidationError{Field: "isbn", Message: err.Error()}
+		}
+		existing, err := tx.Books.GetByISBN(isbn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get book by ISBN: %w", err)
+		}
+		if existing != nil {
+			if existing.DeletedAt.Valid {
+				return existing, nil
+			}
+			// GetByISBN does not load everything an update needs
+			return tx.Books.GetByID(existing.ID)
+		}
+	}
+
+	author := strings.TrimSpace(book.Author)
+	if len(book.Authors) > 0 {
+		names := make([]string, 0, len(book.Authors))
+		for _, a := range book.Authors {
+			names = append(names, strings.TrimSpace(a.Name))
+		}
+		author = strings.Join(names, ", ")
+	}
+	title := strings.TrimSpace(book.Title)
+	if title == "" || author == "" {
+		return nil, nil
+	}
+
+	existing, err := tx.Books.GetByTitleAndAuthor(title, author)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book by title and author: %w", err)
+	}
+	if existing != nil && existing.ISBN != nil && isbn != "" && *existing.ISBN != isbn {
+		return nil, nil
+	}
+	return existing, nil
+}
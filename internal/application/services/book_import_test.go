@@ -0,0 +1,85 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// failingTransactor rolls back the first failures transactions after running
+// them, as if they failed to commit.
+type failingTransactor struct {
+	repositories.Transactor
+	failures int
+}
+
+func (t *failingTransactor) WithinTransaction(fn func(tx repositories.Tx) error) error {
+	return t.Transactor.WithinTransaction(func(tx repositories.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if t.failures > 0 {
+			t.failures--
+			return errors.New("commit failed")
+		}
+		return nil
+	})
+}
+
+func importRows(titles ...string) []services.ImportRow {
+	rows := make([]services.ImportRow, len(titles))
+	for i, title := range titles {
+		rows[i] = services.ImportRow{Line: i + 2, Apply: func(book *models.Book) error {
+			*book = *newTestBook(title, 0)
+			return nil
+		}}
+	}
+	return rows
+}
+
+func TestImportBooksForgetsFailedBatch(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, &failingTransactor{Transactor: ts.transactor, failures: 1}, nopLogger{})
+
+	titles := make([]string, services.ImportBatchSize+1)
+	for i := range services.ImportBatchSize {
+		titles[i] = fmt.Sprintf("Tales from Earthsea %d", i)
+	}
+	// The last row, alone in the second batch, repeats a row of the first
+	titles[services.ImportBatchSize] = titles[0]
+
+	report, err := service.ImportBooks(importRows(titles...), false, services.Actor{UserID: 1})
+	if err != nil {
+		t.Fatalf("ImportBooks: %v", err)
+	}
+	if report.Rejected != services.ImportBatchSize || report.Created != 1 {
+		t.Fatalf("created %d, rejected %d; want the failed batch rejected and its repeat created", report.Created, report.Rejected)
+	}
+	if last := report.Rows[services.ImportBatchSize]; last.Status != services.ImportCreated {
+		t.Errorf("row repeating the failed batch is %q (%s), want created", last.Status, last.Reason)
+	}
+}
+
+func TestImportBooksRejectsRepeatAcrossBatches(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+
+	titles := make([]string, services.ImportBatchSize+1)
+	for i := range services.ImportBatchSize {
+		titles[i] = fmt.Sprintf("Tales from Earthsea %d", i)
+	}
+	titles[services.ImportBatchSize] = titles[0]
+
+	report, err := service.ImportBooks(importRows(titles...), true, services.Actor{UserID: 1})
+	if err != nil {
+		t.Fatalf("ImportBooks: %v", err)
+	}
+	want := fmt.Sprintf("duplicate of line %d", report.Rows[0].Line)
+	if last := report.Rows[services.ImportBatchSize]; last.Status != services.ImportRejected || last.Reason != want {
+		t.Errorf("repeated row is %q (%s), want rejected as %s", last.Status, last.Reason, want)
+	}
+}
@@ -1,72 +1,419 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
-	"hex/internal/adapters/persistence"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
 	"hex/pkg/models"
-
-	"hex/internal/adapters/logging"
 )
 
 type BookService struct {
-	repo   persistence.BookRepository
-	logger *logging.MongoDBLogger
+	repo       repositories.BookRepository
+	transactor repositories.Transactor
+	logger     logging.Logger
+	now        func() time.Time
 }
 
-func NewBookService(repo persistence.BookRepository, logger *logging.MongoDBLogger) *BookService {
-	return &BookService{repo: repo, logger: logger}
+func NewBookService(repo repositories.BookRepository, transactor repositories.Transactor, logger logging.Logger) *BookService {
+	return &BookService{repo: repo, transactor: transactor, logger: logger, now: time.Now}
 }
 
-func (s *BookService) CreateBook(book *models.Book) error {
-	if err := s.repo.Create(book); err != nil {
+// CreateBook adds a book along with book.Availability copies, which may be
+// none.
+func (s *BookService) CreateBook(book *models.Book, actor Actor) error {
+	if err := normalizeBook(book); err != nil {
+		s.logger.Log("ERROR", "Invalid book: "+err.Error())
+		return err
+	}
+	if err := validateNewCopies(book.Availability); err != nil {
+		s.logger.Log("ERROR", "Invalid book: "+err.Error())
+		return err
+	}
+
+	copies := book.Availability
+	book.Version = 1
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		if err := checkISBNUnique(tx, book); err != nil {
+			return err
+		}
+		if err := tx.Books.Create(book); err != nil {
+			return duplicateISBN(err, book)
+		}
+		for seq := 1; seq <= int(copies); seq++ {
+			bookCopy := models.Copy{BookID: book.ID, Barcode: models.CopyBarcode(book.ID, seq)}
+			if err := tx.Copies.Create(&bookCopy); err != nil {
+				return err
+			}
+		}
+		book.Availability = copies
+		if err := recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityBook, book.ID, nil, book); err != nil {
+			return err
+		}
+		return recordEvent(tx, models.EventBookCreated, book.ID, newBookEventData(book), s.now())
+	})
+	if err != nil {
 		s.logger.Log("ERROR", "Failed to create book: "+err.Error())
 		return err
 	}
-	s.logger.Log("INFO", "Book created: "+book.Title)
+	s.logger.Log("INFO", "Book created: "+book.Title, logging.F("book_id", book.ID))
 	return nil
 }
 
-func (s *BookService) ViewAllBooks() ([]models.Book, error) {
-	books, err := s.repo.GetAll()
+func (s *BookService) ListBooks(query repositories.BookQuery) ([]models.Book, int64, error) {
+	books, total, err := s.repo.List(query)
 	if err != nil {
 		s.logger.Log("ERROR", "Failed to retrieve books: "+err.Error())
-		return nil, err
+		return nil, 0, err
 	}
-	s.logger.Log("INFO", "Retrieved all books")
-	return books, nil
+	s.logger.Log("INFO", "Retrieved books", logging.F("count", len(books)), logging.F("total", total), logging.F("page", query.Page))
+	return books, total, nil
 }
 
-func (s *BookService) UpdateBook(book *models.Book) error {
-	if err := s.repo.Update(book); err != nil {
-		s.logger.Log("ERROR", "Failed to update book: "+err.Error())
+// exportBatchSize is how many rows an export reads at a time.
+const exportBatchSize = 500
+
+// ExportBooks passes every book matching filter to fn, a batch at a time and
+// in ID order. It stops at the first error fn returns.
+func (s *BookService) ExportBooks(filter repositories.BookFilter, fn func(books []models.Book) error) error {
+	count := 0
+	err := s.repo.EachBatch(filter, exportBatchSize, func(books []models.Book) error {
+		count += len(books)
+		return fn(books)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to export books: "+err.Error(), logging.F("count", count))
 		return err
 	}
-	s.logger.Log("INFO", "Book updated: "+book.Title)
+	s.logger.Log("INFO", "Exported books", logging.F("count", count))
 	return nil
 }
 
-func (s *BookService) DeleteBook(id string) error {
-	if err := s.repo.Delete(id); err != nil {
-		s.logger.Log("ERROR", "Failed to delete book: "+err.Error())
+func (s *BookService) SearchBooks(text string, limit int) ([]repositories.BookMatch, error) {
+	matches, err := s.repo.Search(text, limit)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to search books: "+err.Error(), logging.F("query", text))
+		return nil, err
+	}
+	s.logger.Log("INFO", "Searched books", logging.F("query", text), logging.F("count", len(matches)))
+	return matches, nil
+}
+
+// UpdateBook locks the book with the given ID, lets change edit it and saves
+// the result as the next version. When version is non-zero the book must
+// still be at that version, otherwise ErrVersionMismatch is returned and
+// nothing is saved.
+func (s *BookService) UpdateBook(id string, version uint, actor Actor, change func(book *models.Book) error) (*models.Book, error) {
+	bookID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return nil, &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
+
+	var book *models.Book
+	err = s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err = tx.Books.GetByIDForUpdate(uint(bookID))
+		if err != nil {
+			return fmt.Errorf("failed to get book: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+		if version != 0 && book.Version != version {
+			return fmt.Errorf("%w: expected version %d, book is at version %d", ErrVersionMismatch, version, book.Version)
+		}
+		before, err := snapshot(book)
+		if err != nil {
+			return err
+		}
+
+		if err := change(book); err != nil {
+			return err
+		}
+		if err := normalizeBook(book); err != nil {
+			return err
+		}
+		if err := checkISBNUnique(tx, book); err != nil {
+			return err
+		}
+		book.Version++
+		if err := tx.Books.Update(book); err != nil {
+			return duplicateISBN(err, book)
+		}
+		return recordAudit(tx, actor, models.AuditActionUpdate, models.AuditEntityBook, book.ID, before, book)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to update book: "+err.Error(), logging.F("book_id", bookID))
+		return nil, err
+	}
+	s.logger.Log("INFO", "Book updated: "+book.Title, logging.F("book_id", book.ID), logging.F("version", book.Version))
+	return book, nil
+}
+
+// DeleteBook marks a book deleted so it can still be restored. It is
+// refused while any copy is on loan, and holds still waiting for the book
+// are cancelled.
+func (s *BookService) DeleteBook(id string, actor Actor) error {
+	bookID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
+
+	err = s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByIDForUpdate(uint(bookID))
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+		if err := checkNoActiveLoans(tx, book.ID); err != nil {
+			return err
+		}
+
+		holds, err := tx.Holds.List(repositories.HoldFilter{BookID: book.ID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		for i := range holds {
+			holds[i].Status = models.HoldStatusCancelled
+			if err := tx.Holds.Update(&holds[i]); err != nil {
+				return fmt.Errorf("failed to cancel hold: %w", err)
+			}
+		}
+		if err := tx.Books.Delete(book.ID); err != nil {
+			return err
+		}
+		deleted, err := tx.Books.GetByIDIncludingDeleted(book.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		return recordAudit(tx, actor, models.AuditActionDelete, models.AuditEntityBook, book.ID, book, deleted)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to delete book: "+err.Error(), logging.F("book_id", bookID))
 		return err
 	}
-	s.logger.Log("INFO", "Book deleted: ID "+id)
+	s.logger.Log("INFO", "Book deleted: ID "+id, logging.F("book_id", bookID))
 	return nil
 }
 
-func (s *BookService) GetBookByID(id string) (*models.Book, error) {
+// RestoreBook brings back a deleted book. Holds cancelled by the deletion
+// stay cancelled.
+func (s *BookService) RestoreBook(id string, actor Actor) (*models.Book, error) {
 	bookID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
 		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return nil, &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
+
+	var book *models.Book
+	err = s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		deleted, err := tx.Books.GetByIDIncludingDeleted(uint(bookID))
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if deleted == nil {
+			return ErrBookNotFound
+		}
+		if !deleted.DeletedAt.Valid {
+			return ErrBookNotDeleted
+		}
+		if err := tx.Books.Restore(deleted.ID); err != nil {
+			return err
+		}
+		book, err = tx.Books.GetByID(deleted.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, models.AuditActionRestore, models.AuditEntityBook, book.ID, deleted, book)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to restore book: "+err.Error(), logging.F("book_id", bookID))
 		return nil, err
 	}
+	s.logger.Log("INFO", "Book restored: "+book.Title, logging.F("book_id", book.ID))
+	return book, nil
+}
+
+// PurgeBook permanently removes a deleted book along with its copies and
+// holds. Its borrowing records and their fines are copied to the archive
+// first, so member history survives. It returns how many records were
+// archived. Books with unpaid fines cannot be purged, since the debt would
+// be lost with them.
+func (s *BookService) PurgeBook(id string, actor Actor) (int, error) {
+	bookID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return 0, &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
+
+	var archived int
+	err = s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByIDIncludingDeleted(uint(bookID))
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+		if !book.DeletedAt.Valid {
+			return fmt.Errorf("%w: delete the book before purging it", ErrBookNotDeleted)
+		}
+		if err := checkNoActiveLoans(tx, book.ID); err != nil {
+			return err
+		}
+
+		records, err := tx.Borrowings.List(repositories.BorrowingFilter{BookID: book.ID})
+		if err != nil {
+			return fmt.Errorf("failed to get borrowing records: %w", err)
+		}
+		now := s.now()
+		for _, record := range records {
+			archive := models.BorrowingArchive{
+				BorrowingRecordID: record.ID,
+				BookID:            book.ID,
+				BookTitle:         book.Title,
+				BookAuthor:        book.Author,
+				BookISBN:          book.ISBN,
+				MemberID:          record.MemberID,
+				BorrowDate:        record.BorrowDate,
+				DueDate:           record.DueDate,
+				ReturnDate:        record.ReturnDate,
+				RenewalCount:      record.RenewalCount,
+				ArchivedBy:        actor.UserID,
+				ArchivedAt:        now,
+			}
+			if record.Copy != nil {
+				archive.CopyBarcode = record.Copy.Barcode
+			}
+
+			fines, err := tx.Fines.List(repositories.FineFilter{BorrowingRecordID: record.ID})
+			if err != nil {
+				return fmt.Errorf("failed to get fines: %w", err)
+			}
+			for _, fine := range fines {
+				if fine.OutstandingCents() > 0 {
+					return fmt.Errorf("%w: fine %d is still owed", ErrUnpaidFines, fine.ID)
+				}
+				archive.AddFine(fine)
+			}
+
+			if err := tx.Archives.Create(&archive); err != nil {
+				return fmt.Errorf("failed to archive borrowing record: %w", err)
+			}
+		}
+		archived = len(records)
+		if err := tx.Books.Purge(book.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, models.AuditActionPurge, models.AuditEntityBook, book.ID, book, nil)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to purge book: "+err.Error(), logging.F("book_id", bookID))
+		return 0, err
+	}
+	s.logger.Log("INFO", "Book purged: ID "+id, logging.F("book_id", bookID), logging.F("archived", archived), logging.F("admin_id", actor.UserID))
+	return archived, nil
+}
+
+func (s *BookService) GetBookByID(id string) (*models.Book, error) {
+	bookID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return nil, &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
 
 	book, err := s.repo.GetByID(uint(bookID))
 	if err != nil {
 		s.logger.Log("ERROR", "Failed to get book by ID: "+err.Error())
 		return nil, err
 	}
-	s.logger.Log("INFO", "Retrieved book by ID: "+id)
+	if book == nil {
+		return nil, ErrBookNotFound
+	}
+	s.logger.Log("INFO", "Retrieved book by ID: "+id, logging.F("book_id", bookID))
 	return book, nil
 }
+
+// BookDetails is a book as shown on its own: Availability is counted live and
+// HoldQueueLength is the number of members still waiting for a copy.
+type BookDetails struct {
+	models.Book
+	HoldQueueLength int
+}
+
+// GetBookDetails reads a book and its hold queue together so the two agree.
+func (s *BookService) GetBookDetails(id string) (*BookDetails, error) {
+	bookID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		s.logger.Log("ERROR", "Invalid book ID: "+err.Error())
+		return nil, &ValidationError{Field: "id", Message: "invalid book ID"}
+	}
+
+	var details *BookDetails
+	err = s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByID(uint(bookID))
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+		waiting, err := tx.Holds.List(repositories.HoldFilter{BookID: book.ID, Statuses: []string{models.HoldStatusWaiting}})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		details = &BookDetails{Book: *book, HoldQueueLength: len(waiting)}
+		return nil
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get book details: "+err.Error(), logging.F("book_id", bookID))
+		return nil, err
+	}
+	s.logger.Log("INFO", "Retrieved book details: "+id, logging.F("book_id", bookID))
+	return details, nil
+}
+
+// checkNoActiveLoans fails with ErrBookOnLoan while a copy of the book is
+// still out.
+func checkNoActiveLoans(tx repositories.Tx, bookID uint) error {
+	loans, err := tx.Borrowings.List(repositories.BorrowingFilter{BookID: bookID, Status: repositories.BorrowingStatusActive})
+	if err != nil {
+		return fmt.Errorf("failed to get borrowing records: %w", err)
+	}
+	if len(loans) > 0 {
+		return fmt.Errorf("%w: active loans: %d", ErrBookOnLoan, len(loans))
+	}
+	return nil
+}
+
+// checkISBNUnique fails with ErrDuplicateISBN when another book, even a
+// deleted one, already has book's ISBN.
+func checkISBNUnique(tx repositories.Tx, book *models.Book) error {
+	if book.ISBN == nil {
+		return nil
+	}
+	existing, err := tx.Books.GetByISBN(*book.ISBN)
+	if err != nil {
+		return fmt.Errorf("failed to get book by ISBN: %w", err)
+	}
+	if existing != nil && existing.ID != book.ID {
+		return fmt.Errorf("%w: book %d has ISBN %s", ErrDuplicateISBN, existing.ID, *book.ISBN)
+	}
+	return nil
+}
+
+// duplicateISBN turns the repository's unique key conflict, which is how a
+// book saved by another transaction after checkISBNUnique shows up, into
+// ErrDuplicateISBN.
+func duplicateISBN(err error, book *models.Book) error {
+	if errors.Is(err, repositories.ErrDuplicate) && book.ISBN != nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateISBN, *book.ISBN)
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+func TestCreateBookCopies(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+
+	for _, availability := range []uint{0, 1, 3, services.MaxNewCopies} {
+		book := newTestBook("The Left Hand of Darkness", availability)
+		if err := service.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+			t.Fatalf("CreateBook with %d copies: %v", availability, err)
+		}
+		copies, err := ts.copies.List(repositories.CopyFilter{BookID: book.ID})
+		if err != nil {
+			t.Fatalf("List copies: %v", err)
+		}
+		if uint(len(copies)) != availability || book.Availability != availability {
+			t.Errorf("availability %d: created %d copies, book reports %d", availability, len(copies), book.Availability)
+		}
+	}
+
+	book := newTestBook("The Dispossessed", services.MaxNewCopies+1)
+	var validation *services.ValidationError
+	if err := service.CreateBook(book, services.Actor{UserID: 1}); !errors.As(err, &validation) || validation.Field != "availability" {
+		t.Fatalf("CreateBook with %d copies: err = %v, want a ValidationError on availability", services.MaxNewCopies+1, err)
+	}
+	if book.ID != 0 {
+		t.Errorf("rejected book was saved with ID %d", book.ID)
+	}
+}
+
+func TestGetBookByIDReportsMissingBook(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+
+	if _, err := service.GetBookByID("42"); !errors.Is(err, services.ErrBookNotFound) {
+		t.Errorf("GetBookByID(42): err = %v, want %v", err, services.ErrBookNotFound)
+	}
+}
+
+func TestGetBookByIDRejectsMalformedID(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+
+	var validation *services.ValidationError
+	if _, err := service.GetBookByID("forty-two"); !errors.As(err, &validation) || validation.Field != "id" {
+		t.Errorf("GetBookByID(forty-two): err = %v, want a ValidationError on id", err)
+	}
+}
+
+func TestUpdateBookChecksVersion(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	book := newTestBook("The Word for World Is Forest", 2)
+	if err := service.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	rename := func(title string) func(book *models.Book) error {
+		return func(book *models.Book) error {
+			book.Title = title
+			return nil
+		}
+	}
+
+	updated, err := service.UpdateBook(idOf(book), book.Version, services.Actor{UserID: 1}, rename("Vaster than Empires"))
+	if err != nil {
+		t.Fatalf("UpdateBook: %v", err)
+	}
+	if updated.Version != book.Version+1 || updated.Availability != 2 {
+		t.Errorf("updated book at version %d with %d copies, want version %d with 2", updated.Version, updated.Availability, book.Version+1)
+	}
+
+	// A second librarian still holding the first version loses
+	if _, err := service.UpdateBook(idOf(book), book.Version, services.Actor{UserID: 2}, rename("Stale")); !errors.Is(err, services.ErrVersionMismatch) {
+		t.Fatalf("UpdateBook at a stale version: err = %v, want %v", err, services.ErrVersionMismatch)
+	}
+	if current, err := service.GetBookByID(idOf(book)); err != nil || current.Title != "Vaster than Empires" {
+		t.Errorf("GetBookByID = %+v, %v; want the stale update discarded", current, err)
+	}
+
+	// Version 0 skips the check
+	if _, err := service.UpdateBook(idOf(book), 0, services.Actor{UserID: 2}, rename("The Word for World Is Forest")); err != nil {
+		t.Errorf("UpdateBook without a version: %v", err)
+	}
+}
+
+// blindISBNCheck hides every book from GetByISBN, as if another transaction
+// saved the same ISBN between the service's check and its write.
+func blindISBNCheck(ts *testStore) repositories.Transactor {
+	return txHook{Transactor: ts.transactor, hook: func(tx *repositories.Tx) {
+		tx.Books = noISBNLookup{tx.Books}
+	}}
+}
+
+type noISBNLookup struct{ repositories.BookRepository }
+
+func (noISBNLookup) GetByISBN(string) (*models.Book, error) { return nil, nil }
+
+func withISBN(book *models.Book, isbn string) *models.Book {
+	book.ISBN = &isbn
+	return book
+}
+
+func TestCreateBookReportsConcurrentISBN(t *testing.T) {
+	ts := newTestStore()
+	if err := ts.books.Create(withISBN(newTestBook("The Word for World Is Forest", 0), "9780547773742")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	service := services.NewBookService(ts.books, blindISBNCheck(ts), nopLogger{})
+
+	book := withISBN(newTestBook("The Telling", 0), "9780547773742")
+	if err := service.CreateBook(book, services.Actor{UserID: 1}); !errors.Is(err, services.ErrDuplicateISBN) {
+		t.Errorf("CreateBook: err = %v, want %v", err, services.ErrDuplicateISBN)
+	}
+}
+
+func TestUpdateBookReportsConcurrentISBN(t *testing.T) {
+	ts := newTestStore()
+	if err := ts.books.Create(withISBN(newTestBook("The Word for World Is Forest", 0), "9780547773742")); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	book := newTestBook("The Telling", 0)
+	if err := ts.books.Create(book); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	service := services.NewBookService(ts.books, blindISBNCheck(ts), nopLogger{})
+
+	_, err := service.UpdateBook(idOf(book), book.Version, services.Actor{UserID: 1}, func(book *models.Book) error {
+		withISBN(book, "9780547773742")
+		return nil
+	})
+	if !errors.Is(err, services.ErrDuplicateISBN) {
+		t.Errorf("UpdateBook: err = %v, want %v", err, services.ErrDuplicateISBN)
+	}
+}
+
+func TestGetBookDetailsCountsWaitingHolds(t *testing.T) {
+	ts := newTestStore()
+	service := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	book := newTestBook("Tehanu", 0)
+	if err := service.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	for memberID, status := range map[uint]string{20: models.HoldStatusWaiting, 21: models.HoldStatusWaiting, 22: models.HoldStatusCancelled} {
+		if err := ts.holds.Create(&models.Hold{BookID: book.ID, MemberID: memberID, Status: status}); err != nil {
+			t.Fatalf("Create hold: %v", err)
+		}
+	}
+
+	details, err := service.GetBookDetails(idOf(book))
+	if err != nil {
+		t.Fatalf("GetBookDetails: %v", err)
+	}
+	if details.HoldQueueLength != 2 || details.Title != "Tehanu" {
+		t.Errorf("details = %q with %d waiting, want Tehanu with 2", details.Title, details.HoldQueueLength)
+	}
+
+	if _, err := service.GetBookDetails("42"); !errors.Is(err, services.ErrBookNotFound) {
+		t.Errorf("GetBookDetails(42): err = %v, want %v", err, services.ErrBookNotFound)
+	}
+}
+
+func TestDeleteBookRefusedWhileOnLoan(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14}, services.FinePolicy{}, services.HoldPolicy{PickupWindow: time.Hour}, nopLogger{})
+	book := newTestBook("The Beginning Place", 1)
+	if err := books.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+		t.Fatalf("BorrowBook: %v", err)
+	}
+
+	if err := books.DeleteBook(idOf(book), services.Actor{UserID: 1}); !errors.Is(err, services.ErrBookOnLoan) {
+		t.Fatalf("DeleteBook while on loan: err = %v, want %v", err, services.ErrBookOnLoan)
+	}
+	if _, err := books.GetBookByID(idOf(book)); err != nil {
+		t.Errorf("book gone after a refused delete: %v", err)
+	}
+}
+
+func TestDeleteRestoreAndPurgeBook(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14}, services.FinePolicy{}, services.HoldPolicy{PickupWindow: time.Hour}, nopLogger{})
+	admin := services.Actor{UserID: 1, Role: "admin"}
+	book := newTestBook("Malafrena", 1)
+	if err := books.CreateBook(book, admin); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+		t.Fatalf("BorrowBook: %v", err)
+	}
+	loans, err := borrowings.GetMyBorrowings(10, repositories.BorrowingStatusActive)
+	if err != nil || len(loans) != 1 {
+		t.Fatalf("GetMyBorrowings = %d loans, %v; want 1", len(loans), err)
+	}
+	if err := borrowings.ReturnBook(loans[0].ID, member(10)); err != nil {
+		t.Fatalf("ReturnBook: %v", err)
+	}
+
+	if _, err := books.PurgeBook(idOf(book), admin); !errors.Is(err, services.ErrBookNotDeleted) {
+		t.Fatalf("PurgeBook before deleting: err = %v, want %v", err, services.ErrBookNotDeleted)
+	}
+	if err := books.DeleteBook(idOf(book), admin); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	if _, err := books.GetBookByID(idOf(book)); !errors.Is(err, services.ErrBookNotFound) {
+		t.Errorf("GetBookByID after delete: err = %v, want %v", err, services.ErrBookNotFound)
+	}
+
+	restored, err := books.RestoreBook(idOf(book), admin)
+	if err != nil || restored.DeletedAt.Valid {
+		t.Fatalf("RestoreBook = %+v, %v; want the book back", restored, err)
+	}
+	if _, err := books.RestoreBook(idOf(book), admin); !errors.Is(err, services.ErrBookNotDeleted) {
+		t.Errorf("RestoreBook twice: err = %v, want %v", err, services.ErrBookNotDeleted)
+	}
+
+	if err := books.DeleteBook(idOf(book), admin); err != nil {
+		t.Fatalf("DeleteBook again: %v", err)
+	}
+	archived, err := books.PurgeBook(idOf(book), admin)
+	if err != nil || archived != 1 {
+		t.Fatalf("PurgeBook = %d, %v; want the one loan archived", archived, err)
+	}
+	if _, err := books.RestoreBook(idOf(book), admin); !errors.Is(err, services.ErrBookNotFound) {
+		t.Errorf("RestoreBook after purge: err = %v, want %v", err, services.ErrBookNotFound)
+	}
+	if records, _ := ts.borrowings.List(repositories.BorrowingFilter{BookID: book.ID}); len(records) != 0 {
+		t.Errorf("%d borrowing records left after purge, want 0", len(records))
+	}
+}
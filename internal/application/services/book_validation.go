@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"hex/pkg/models"
+)
+
+// maxNewCopies caps how many copies a book can be created with at once.
+const maxNewCopies = 1000
+
+// languageTag loosely matches BCP 47 tags such as "en", "pt-BR" or "zh-Hant".
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// normalizeBook checks a book before it is saved and tidies it in place:
+// names are trimmed and deduplicated, the byline is rebuilt from Authors and
+// the ISBN is stored as 13 digits.
+func normalizeBook(book *models.Book) error {
+	book.Title = strings.TrimSpace(book.Title)
+	if book.Title == "" {
+		return &ValidationError{Field: "title", Message: "title is required"}
+	}
+	if time.Time(book.PublicationDate).IsZero() {
+		return &ValidationError{Field: "publication_date", Message: "publication_date is required"}
+	}
+
+	// A book saved with only a byline gets it as its single author
+	if len(book.Authors) == 0 && strings.TrimSpace(book.Author) != "" {
+		book.Authors = []models.Author{{Name: book.Author}}
+	}
+	var authorNames []string
+	book.Authors, authorNames = uniqueNames(book.Authors, func(a models.Author) string { return a.Name }, func(name string) models.Author {
+		return models.Author{Name: name}
+	})
+	if len(book.Authors) == 0 {
+		return &ValidationError{Field: "authors", Message: "at least one author is required"}
+	}
+	book.Author = strings.Join(authorNames, ", ")
+
+	book.Subjects, _ = uniqueNames(book.Subjects, func(s models.Subject) string { return s.Name }, func(name string) models.Subject {
+		return models.Subject{Name: name}
+	})
+
+	if book.ISBN != nil {
+		if strings.TrimSpace(*book.ISBN) == "" {
+			book.ISBN = nil
+		} else {
+			isbn, err := models.NormalizeISBN(*book.ISBN)
+			if err != nil {
+				return &ValidationError{Field: "isbn", Message: err.Error()}
+			}
+			book.ISBN = &isbn
+		}
+	}
+
+	book.Language = strings.TrimSpace(book.Language)
+	if book.Language != "" && !languageTag.MatchString(book.Language) {
+		return &ValidationError{Field: "language", Message: "language must be a language tag such as en or pt-BR"}
+	}
+
+	book.CoverURL = strings.TrimSpace(book.CoverURL)
+	if book.CoverURL != "" {
+		u, err := url.ParseRequestURI(book.CoverURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &ValidationError{Field: "cover_url", Message: "cover_url must be an http or https URL"}
+		}
+	}
+
+	book.Publisher = strings.TrimSpace(book.Publisher)
+	book.Edition = strings.TrimSpace(book.Edition)
+	return nil
+}
+
+// uniqueNames trims the names of items, dropping empty ones and later
+// duplicates that differ only in case. It returns fresh items made by
+// build, so IDs supplied by clients are ignored, along with the names kept.
+func uniqueNames[T any](items []T, name func(T) string, build func(string) T) ([]T, []string) {
+	var (
+		kept  []T
+		names []string
+		seen  = map[string]bool{}
+	)
+	for _, item := range items {
+		n := strings.TrimSpace(name(item))
+		key := strings.ToLower(n)
+		if n == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, build(n))
+		names = append(names, n)
+	}
+	return kept, names
+}
+
+// validateNewCopies checks the number of copies a new book starts with.
+func validateNewCopies(copies uint) error {
+	if copies > maxNewCopies {
+		return &ValidationError{Field: "availability", Message: fmt.Sprintf("availability must be at most %d", maxNewCopies)}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appauth "hex/internal/application/auth"
+	"hex/pkg/models"
+)
+
+// Codes identifying which borrowing rule a request broke.
+const (
+	ViolationLoanLimit        = "loan_limit_reached"
+	ViolationDuplicateLoan    = "duplicate_loan"
+	ViolationOverdueLoans     = "overdue_loans"
+	ViolationOutstandingFines = "outstanding_fines"
+)
+
+// PolicyViolation is returned when a member is not allowed to borrow. Code
+// is one of the Violation constants and is stable for clients to match on.
+type PolicyViolation struct {
+	Code    string
+	Message string
+}
+
+func (v *PolicyViolation) Error() string {
+	return v.Message
+}
+
+// BorrowingPolicy decides whether a member may take out another loan.
+type BorrowingPolicy struct {
+	// MaxLoans caps how many books a member may have out at once; zero means
+	// no limit.
+	MaxLoans int
+	// RoleMaxLoans overrides MaxLoans for particular roles.
+	RoleMaxLoans map[appauth.Role]int
+	// BlockOverdue stops members with an overdue loan from borrowing more.
+	BlockOverdue bool
+}
+
+// LoanLimit returns how many concurrent loans role is allowed, zero meaning
+// no limit.
+func (p BorrowingPolicy) LoanLimit(role appauth.Role) int {
+	if limit, ok := p.RoleMaxLoans[role]; ok {
+		return limit
+	}
+	return p.MaxLoans
+}
+
+// Check returns a *PolicyViolation if a member with role and the given
+// active loans may not borrow book at now, and nil otherwise.
+func (p BorrowingPolicy) Check(role appauth.Role, book models.Book, loans []models.BorrowingRecord, now time.Time) error {
+	for _, loan := range loans {
+		if p.BlockOverdue && loan.IsOverdue(now) {
+			return &PolicyViolation{
+				Code:    ViolationOverdueLoans,
+				Message: "return your overdue books before borrowing more",
+			}
+		}
+	}
+
+	for _, loan := range loans {
+		if loan.BookID == book.ID || sameTitle(loan.Book, book) {
+			return &PolicyViolation{
+				Code:    ViolationDuplicateLoan,
+				Message: fmt.Sprintf("you already have %q on loan", book.Title),
+			}
+		}
+	}
+
+	if limit := p.LoanLimit(role); limit > 0 && len(loans) >= limit {
+		return &PolicyViolation{
+			Code:    ViolationLoanLimit,
+			Message: fmt.Sprintf("you may have at most %d books on loan", limit),
+		}
+	}
+
+	return nil
+}
+
+// sameTitle reports whether a and b are the same work catalogued twice.
+func sameTitle(a, b models.Book) bool {
+	return a.Title != "" &&
+		strings.EqualFold(a.Title, b.Title) &&
+		strings.EqualFold(a.Author, b.Author)
+}
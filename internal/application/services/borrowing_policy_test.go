@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	appauth "hex/internal/application/auth"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// loanOf is an active loan of book, due at due.
+func loanOf(book models.Book, due time.Time) models.BorrowingRecord {
+	return models.BorrowingRecord{BookID: book.ID, Book: book, DueDate: due}
+}
+
+func violationCode(err error) string {
+	var violation *services.PolicyViolation
+	if errors.As(err, &violation) {
+		return violation.Code
+	}
+	return ""
+}
+
+func TestBorrowingPolicyCheck(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	book := *newTestBook("The Eye of the Heron", 1)
+	book.ID = 1
+	other := *newTestBook("Malafrena", 1)
+	other.ID = 2
+	reprint := book
+	reprint.ID = 3
+	policy := services.BorrowingPolicy{
+		MaxLoans:     2,
+		RoleMaxLoans: map[appauth.Role]int{"librarian": 0},
+		BlockOverdue: true,
+	}
+	later := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name  string
+		role  appauth.Role
+		book  models.Book
+		loans []models.BorrowingRecord
+		want  string
+	}{
+		{"first loan", "member", book, nil, ""},
+		{"same book again", "member", book, []models.BorrowingRecord{loanOf(book, later)}, services.ViolationDuplicateLoan},
+		{"same title catalogued twice", "member", reprint, []models.BorrowingRecord{loanOf(book, later)}, services.ViolationDuplicateLoan},
+		{"overdue loan", "member", other, []models.BorrowingRecord{loanOf(book, now.Add(-time.Hour))}, services.ViolationOverdueLoans},
+		{"at the limit", "member", book, []models.BorrowingRecord{loanOf(other, later), loanOf(models.Book{}, later)}, services.ViolationLoanLimit},
+		{"role without a limit", "librarian", book, []models.BorrowingRecord{loanOf(other, later), loanOf(models.Book{}, later)}, ""},
+	}
+	for _, tt := range tests {
+		if got := violationCode(policy.Check(tt.role, tt.book, tt.loans, now)); got != tt.want {
+			t.Errorf("%s: violation %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBorrowBookAppliesRoleLimit(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	first, second := newTestBook("Four Ways to Forgiveness", 1), newTestBook("Searoad", 1)
+	for _, book := range []*models.Book{first, second} {
+		if err := books.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+			t.Fatalf("CreateBook: %v", err)
+		}
+	}
+	policy := services.BorrowingPolicy{MaxLoans: 3, RoleMaxLoans: map[appauth.Role]int{"member": 1}}
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, policy, services.LoanPolicy{DefaultDays: 14}, services.FinePolicy{BlockThresholdCents: -1}, services.HoldPolicy{}, nopLogger{})
+
+	if err := borrowings.BorrowBook(first.ID, member(10)); err != nil {
+		t.Fatalf("first BorrowBook: %v", err)
+	}
+	if err := borrowings.BorrowBook(second.ID, member(10)); violationCode(err) != services.ViolationLoanLimit {
+		t.Errorf("second BorrowBook: err = %v, want a %s violation", err, services.ViolationLoanLimit)
+	}
+	if err := borrowings.BorrowBook(second.ID, services.Actor{UserID: 20, Role: "librarian"}); err != nil {
+		t.Errorf("BorrowBook under the default limit: %v", err)
+	}
+}
@@ -2,145 +2,438 @@ package services
 
 import (
 	"fmt"
-	"hex/internal/adapters/persistence"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
 	"hex/pkg/models"
 	"time"
-
-	"hex/internal/adapters/logging"
 )
 
 type BorrowingService interface {
-	BorrowBook(bookID uint, memberID uint) error
-	ReturnBook(borrowingRecordID uint, memberID uint) error
-	GetMyBorrowings(memberID uint) ([]models.BorrowingRecord, error)
-	GetAllBorrowingRecords() ([]models.BorrowingRecord, error)
+	// BorrowBook lends book to the actor, who is subject to the borrowing
+	// policy for their role. Broken rules are reported as a
+	// *PolicyViolation.
+	BorrowBook(bookID uint, actor Actor) error
+	// ReturnBook and RenewBorrowing act on one of the actor's own loans.
+	ReturnBook(borrowingRecordID uint, actor Actor) error
+	RenewBorrowing(borrowingRecordID uint, actor Actor) (*models.BorrowingRecord, error)
+	// NotifyOverdueLoans raises LoanOverdue for every loan that has passed
+	// its due date since the last call, once per due date, and returns how
+	// many it raised. It is meant to be called periodically.
+	NotifyOverdueLoans() (int, error)
+	// GetMyBorrowings lists a member's loans; status is one of
+	// repositories.BorrowingStatuses, or empty for all of them.
+	GetMyBorrowings(memberID uint, status string) ([]models.BorrowingRecord, error)
+	GetAllBorrowingRecords(filter repositories.BorrowingFilter) ([]models.BorrowingRecord, error)
+	// ExportBorrowingRecords passes every record matching filter to fn, a
+	// batch at a time and in ID order. It stops at the first error fn
+	// returns.
+	ExportBorrowingRecords(filter repositories.BorrowingFilter, fn func(records []models.BorrowingRecord) error) error
 }
 
 type borrowingService struct {
-	bookRepo      persistence.BookRepository
-	borrowingRepo persistence.BorrowingRepository
-	logger        *logging.MongoDBLogger
+	borrowingRepo repositories.BorrowingRepository
+	transactor    repositories.Transactor
+	policy        BorrowingPolicy
+	loanPolicy    LoanPolicy
+	finePolicy    FinePolicy
+	holdPolicy    HoldPolicy
+	logger        logging.Logger
+	now           func() time.Time
 }
 
-func NewBorrowingService(bookRepo persistence.BookRepository, borrowingRepo persistence.BorrowingRepository, logger *logging.MongoDBLogger) BorrowingService {
+func NewBorrowingService(borrowingRepo repositories.BorrowingRepository, transactor repositories.Transactor, policy BorrowingPolicy, loanPolicy LoanPolicy, finePolicy FinePolicy, holdPolicy HoldPolicy, logger logging.Logger) BorrowingService {
 	return &borrowingService{
-		bookRepo:      bookRepo,
 		borrowingRepo: borrowingRepo,
+		transactor:    transactor,
+		policy:        policy,
+		loanPolicy:    loanPolicy,
+		finePolicy:    finePolicy,
+		holdPolicy:    holdPolicy,
 		logger:        logger,
+		now:           time.Now,
 	}
 }
 
-func (s *borrowingService) BorrowBook(bookID uint, memberID uint) error {
-	// Check if the book is available
-	book, err := s.bookRepo.GetByID(bookID)
-	if err != nil {
-		s.logger.Log("ERROR", "Failed to get book by ID: "+err.Error())
-		return err
-	}
-	if book == nil {
-		err := fmt.Errorf("book not found")
-		s.logger.Log("ERROR", err.Error())
-		return err
-	}
-	if book.Availability == 0 {
-		err := fmt.Errorf("book is not available")
-		s.logger.Log("ERROR", err.Error())
-		return err
-	}
+func (s *borrowingService) BorrowBook(bookID uint, actor Actor) error {
+	memberID := actor.UserID
+	var copyID uint
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		// Lock the book row so concurrent borrows of the last copy are serialized
+		book, err := tx.Books.GetByIDForUpdate(bookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
 
-	// Create a new borrowing record
-	borrowingRecord := models.BorrowingRecord{
-		BookID:     bookID,
-		MemberID:   memberID,
-		BorrowDate: time.Now(),
-	}
-	if err := s.borrowingRepo.Create(&borrowingRecord); err != nil {
-		s.logger.Log("ERROR", "Failed to create borrowing record: "+err.Error())
-		return err
-	}
+		// Members with too much unpaid in fines must settle up first
+		balance, err := tx.Fines.OutstandingBalance(memberID)
+		if err != nil {
+			return fmt.Errorf("failed to get outstanding fines: %w", err)
+		}
+		if s.finePolicy.Blocks(balance) {
+			return &PolicyViolation{
+				Code:    ViolationOutstandingFines,
+				Message: fmt.Sprintf("outstanding fines of %d cents exceed the limit of %d cents", balance, s.finePolicy.BlockThresholdCents),
+			}
+		}
+
+		// Loan limits, duplicate titles and overdue items
+		now := s.now()
+		loans, err := tx.Borrowings.List(repositories.BorrowingFilter{MemberID: memberID, Status: repositories.BorrowingStatusActive})
+		if err != nil {
+			return fmt.Errorf("failed to get borrowing records: %w", err)
+		}
+		if err := s.policy.Check(actor.Role, *book, loans, now); err != nil {
+			return err
+		}
+
+		// Copies set aside for ready holds may only go to those members
+		free, err := freeCopies(tx, book)
+		if err != nil {
+			return err
+		}
+		ready, err := serveHoldQueue(tx, book, free, now, s.holdPolicy)
+		if err != nil {
+			return err
+		}
+		heldForMember := false
+		for _, hold := range ready {
+			if hold.MemberID == memberID {
+				heldForMember = true
+			}
+		}
+		if !heldForMember && book.Availability > 0 && book.Availability <= uint(len(ready)) {
+			return fmt.Errorf("%w: reserved for members with holds", ErrUnavailable)
+		}
+
+		// Take a copy off the shelf, if one is left
+		bookCopy, err := tx.Copies.NextAvailableForUpdate(bookID)
+		if err != nil {
+			return fmt.Errorf("failed to get available copy: %w", err)
+		}
+		if bookCopy == nil {
+			return ErrUnavailable
+		}
+		bookCopy.Status = models.CopyStatusOnLoan
+		if err := tx.Copies.Update(bookCopy); err != nil {
+			return fmt.Errorf("failed to update copy: %w", err)
+		}
 
-	// Update the book availability
-	book.Availability--
-	if err := s.bookRepo.Update(book); err != nil {
-		s.logger.Log("ERROR", "Failed to update book availability: "+err.Error())
+		// The member's own hold on the book, ready or not, is now fulfilled
+		holds, err := tx.Holds.List(repositories.HoldFilter{BookID: bookID, MemberID: memberID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		for _, hold := range holds {
+			hold.Status = models.HoldStatusFulfilled
+			if err := tx.Holds.Update(&hold); err != nil {
+				return fmt.Errorf("failed to fulfil hold: %w", err)
+			}
+		}
+
+		// Create a new borrowing record, due after the book's loan period
+		borrowingRecord := models.BorrowingRecord{
+			BookID:     bookID,
+			CopyID:     &bookCopy.ID,
+			MemberID:   memberID,
+			BorrowDate: now,
+			DueDate:    s.loanPolicy.DueDate(*book, now),
+		}
+		if err := tx.Borrowings.Create(&borrowingRecord); err != nil {
+			return fmt.Errorf("failed to create borrowing record: %w", err)
+		}
+		copyID = bookCopy.ID
+		if err := recordAudit(tx, actor, models.AuditActionBorrow, models.AuditEntityBorrowingRecord, borrowingRecord.ID, nil, borrowingRecord); err != nil {
+			return err
+		}
+		return recordEvent(tx, models.EventBookBorrowed, borrowingRecord.ID, newLoanEventData(&borrowingRecord), now)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("book_id", bookID), logging.F("member_id", memberID))
 		return err
 	}
 
-	s.logger.Log("INFO", fmt.Sprintf("Book borrowed: bookID=%d, memberID=%d", bookID, memberID))
+	s.logger.Log("INFO", "Book borrowed", logging.F("book_id", bookID), logging.F("copy_id", copyID), logging.F("member_id", memberID))
 	return nil
 }
 
-func (s *borrowingService) ReturnBook(borrowingRecordID uint, memberID uint) error {
-	borrowingRecord, err := s.borrowingRepo.GetByID(borrowingRecordID)
+func (s *borrowingService) ReturnBook(borrowingRecordID uint, actor Actor) error {
+	memberID := actor.UserID
+	var (
+		bookID uint
+		fine   *models.Fine
+	)
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		// Lock the record so the same loan cannot be returned twice concurrently
+		borrowingRecord, err := tx.Borrowings.GetByIDForUpdate(borrowingRecordID)
+		if err != nil {
+			return fmt.Errorf("failed to get borrowing record by ID: %w", err)
+		}
+		if borrowingRecord == nil {
+			return ErrBorrowingRecordNotFound
+		}
+
+		// Check if the book belongs to the user
+		if borrowingRecord.MemberID != memberID {
+			return fmt.Errorf("%w: you can only return books you borrowed", ErrNotOwner)
+		}
+
+		// Check if the book is already returned
+		if borrowingRecord.IsReturned() {
+			return ErrAlreadyReturned
+		}
+
+		book, err := tx.Books.GetByIDForUpdate(borrowingRecord.BookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+
+		before, err := snapshot(borrowingRecord)
+		if err != nil {
+			return err
+		}
+		returnDate := s.now()
+		borrowingRecord.ReturnDate = &returnDate
+		if err := tx.Borrowings.Update(borrowingRecord); err != nil {
+			return fmt.Errorf("failed to update borrowing record: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionReturn, models.AuditEntityBorrowingRecord, borrowingRecord.ID, before, borrowingRecord); err != nil {
+			return err
+		}
+
+		// Put the copy back on the shelf
+		free, err := freeCopies(tx, book)
+		if err != nil {
+			return err
+		}
+		if borrowingRecord.CopyID != nil {
+			bookCopy, err := tx.Copies.GetByIDForUpdate(*borrowingRecord.CopyID)
+			if err != nil {
+				return fmt.Errorf("failed to get copy by ID: %w", err)
+			}
+			if bookCopy != nil && bookCopy.Status == models.CopyStatusOnLoan {
+				bookCopy.Status = models.CopyStatusAvailable
+				if err := tx.Copies.Update(bookCopy); err != nil {
+					return fmt.Errorf("failed to update copy: %w", err)
+				}
+				book.Availability++
+			}
+		}
+
+		// Set the copy aside for the next member waiting for it
+		if _, err := serveHoldQueue(tx, book, free, returnDate, s.holdPolicy); err != nil {
+			return err
+		}
+
+		// Charge for a late return
+		daysLate, amount := s.finePolicy.Assess(borrowingRecord.DueDate, returnDate)
+		if amount > 0 {
+			fine = &models.Fine{
+				BorrowingRecordID: borrowingRecord.ID,
+				MemberID:          memberID,
+				DaysLate:          daysLate,
+				AmountCents:       amount,
+				Status:            models.FineStatusUnpaid,
+			}
+			if err := tx.Fines.Create(fine); err != nil {
+				return fmt.Errorf("failed to create fine: %w", err)
+			}
+			if err := recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityFine, fine.ID, nil, fine); err != nil {
+				return err
+			}
+		}
+
+		returned := newLoanEventData(borrowingRecord)
+		if fine != nil {
+			returned.FineCents = fine.AmountCents
+		}
+		if err := recordEvent(tx, models.EventBookReturned, borrowingRecord.ID, returned, returnDate); err != nil {
+			return err
+		}
+
+		bookID = book.ID
+		return nil
+	})
 	if err != nil {
-		s.logger.Log("ERROR", "Failed to get borrowing record by ID: "+err.Error())
-		return err
-	}
-	if borrowingRecord == nil {
-		err := fmt.Errorf("borrowing record not found")
-		s.logger.Log("ERROR", err.Error())
+		s.logger.Log("ERROR", err.Error(), logging.F("borrowing_record_id", borrowingRecordID), logging.F("member_id", memberID))
 		return err
 	}
 
-	// Check if the book belongs to the user
-	if borrowingRecord.MemberID != memberID {
-		err := fmt.Errorf("unauthorized: you can only return books you borrowed")
-		s.logger.Log("ERROR", err.Error())
-		return err
+	s.logger.Log("INFO", "Book returned", logging.F("book_id", bookID), logging.F("borrowing_record_id", borrowingRecordID), logging.F("member_id", memberID))
+	if fine != nil {
+		s.logger.Log("INFO", "Fine charged for late return", logging.F("fine_id", fine.ID), logging.F("member_id", memberID), logging.F("days_late", fine.DaysLate), logging.F("amount_cents", fine.AmountCents))
 	}
+	return nil
+}
 
-	// Check if the book is already returned
-	if !borrowingRecord.ReturnDate.IsZero() {
-		err := fmt.Errorf("book is already returned")
-		s.logger.Log("ERROR", err.Error())
-		return err
-	}
+func (s *borrowingService) RenewBorrowing(borrowingRecordID uint, actor Actor) (*models.BorrowingRecord, error) {
+	memberID := actor.UserID
+	var renewed *models.BorrowingRecord
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		borrowingRecord, err := tx.Borrowings.GetByIDForUpdate(borrowingRecordID)
+		if err != nil {
+			return fmt.Errorf("failed to get borrowing record by ID: %w", err)
+		}
+		if borrowingRecord == nil {
+			return ErrBorrowingRecordNotFound
+		}
+		if borrowingRecord.MemberID != memberID {
+			return fmt.Errorf("%w: you can only renew books you borrowed", ErrNotOwner)
+		}
+		if borrowingRecord.IsReturned() {
+			return ErrAlreadyReturned
+		}
+		if borrowingRecord.RenewalCount >= s.loanPolicy.MaxRenewals {
+			return fmt.Errorf("%w: already renewed the maximum of %d times", ErrRenewalRefused, s.loanPolicy.MaxRenewals)
+		}
+
+		// Members waiting in the hold queue get the book first
+		holds, err := tx.Holds.List(repositories.HoldFilter{BookID: borrowingRecord.BookID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		if len(holds) > 0 {
+			return fmt.Errorf("%w: other members have holds on the book", ErrRenewalRefused)
+		}
+
+		book, err := tx.Books.GetByID(borrowingRecord.BookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+
+		before, err := snapshot(borrowingRecord)
+		if err != nil {
+			return err
+		}
 
-	book, err := s.bookRepo.GetByID(borrowingRecord.BookID)
+		// Extend from the current due date so renewing early loses no days
+		from := borrowingRecord.DueDate
+		if from.IsZero() {
+			from = s.loanPolicy.DueDate(*book, borrowingRecord.BorrowDate)
+		}
+		borrowingRecord.DueDate = s.loanPolicy.DueDate(*book, from)
+		borrowingRecord.RenewalCount++
+		// A renewed loan is overdue again only after its new due date
+		borrowingRecord.OverdueNotifiedAt = nil
+		if err := tx.Borrowings.Update(borrowingRecord); err != nil {
+			return fmt.Errorf("failed to update borrowing record: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionRenew, models.AuditEntityBorrowingRecord, borrowingRecord.ID, before, borrowingRecord); err != nil {
+			return err
+		}
+
+		borrowingRecord.Book = *book
+		renewed = borrowingRecord
+		return nil
+	})
 	if err != nil {
-		s.logger.Log("ERROR", "Failed to get book by ID: "+err.Error())
-		return err
-	}
-	if book == nil {
-		err := fmt.Errorf("book not found")
-		s.logger.Log("ERROR", err.Error())
-		return err
+		s.logger.Log("ERROR", err.Error(), logging.F("borrowing_record_id", borrowingRecordID), logging.F("member_id", memberID))
+		return nil, err
 	}
 
-	borrowingRecord.ReturnDate = time.Now()
-	if err := s.borrowingRepo.Update(borrowingRecord); err != nil {
-		s.logger.Log("ERROR", "Failed to update borrowing record: "+err.Error())
-		return err
+	renewed.Overdue = renewed.IsOverdue(s.now())
+	s.logger.Log("INFO", "Loan renewed", logging.F("borrowing_record_id", borrowingRecordID), logging.F("member_id", memberID), logging.F("due_date", renewed.DueDate), logging.F("renewal_count", renewed.RenewalCount))
+	return renewed, nil
+}
+
+func (s *borrowingService) NotifyOverdueLoans() (int, error) {
+	now := s.now()
+	overdue, err := s.borrowingRepo.List(repositories.BorrowingFilter{Status: repositories.BorrowingStatusOverdue, Now: now})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get overdue loans: "+err.Error())
+		return 0, err
 	}
 
-	book.Availability++
-	if err := s.bookRepo.Update(book); err != nil {
-		s.logger.Log("ERROR", "Failed to update book availability: "+err.Error())
-		return err
+	notified := 0
+	for _, loan := range overdue {
+		if loan.OverdueNotifiedAt != nil {
+			continue
+		}
+		raised := false
+		err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+			// Recheck under lock in case the loan was returned or renewed since
+			borrowingRecord, err := tx.Borrowings.GetByIDForUpdate(loan.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get borrowing record by ID: %w", err)
+			}
+			if borrowingRecord == nil || !borrowingRecord.IsOverdue(now) || borrowingRecord.OverdueNotifiedAt != nil {
+				return nil
+			}
+			borrowingRecord.OverdueNotifiedAt = &now
+			if err := tx.Borrowings.Update(borrowingRecord); err != nil {
+				return fmt.Errorf("failed to update borrowing record: %w", err)
+			}
+			raised = true
+			return recordEvent(tx, models.EventLoanOverdue, borrowingRecord.ID, newLoanEventData(borrowingRecord), now)
+		})
+		if err != nil {
+			s.logger.Log("ERROR", "Failed to raise overdue event: "+err.Error(), logging.F("borrowing_record_id", loan.ID))
+			return notified, err
+		}
+		if raised {
+			notified++
+		}
 	}
 
-	s.logger.Log("INFO", fmt.Sprintf("Book returned: bookID=%d, memberID=%d", borrowingRecord.BookID, memberID))
-	return nil
+	if notified > 0 {
+		s.logger.Log("INFO", "Raised overdue loan events", logging.F("count", notified))
+	}
+	return notified, nil
 }
 
-func (s *borrowingService) GetMyBorrowings(memberID uint) ([]models.BorrowingRecord, error) {
-	borrowingRecords, err := s.borrowingRepo.GetByMemberID(memberID)
+func (s *borrowingService) GetMyBorrowings(memberID uint, status string) ([]models.BorrowingRecord, error) {
+	borrowingRecords, err := s.borrowingRepo.List(repositories.BorrowingFilter{MemberID: memberID, Status: status, Now: s.now()})
 	if err != nil {
-		s.logger.Log("ERROR", "Failed to get borrowing records by member ID: "+err.Error())
+		s.logger.Log("ERROR", "Failed to get borrowing records by member ID: "+err.Error(), logging.F("member_id", memberID))
 		return nil, err
 	}
 
-	s.logger.Log("INFO", fmt.Sprintf("Retrieved borrowing records for memberID=%d", memberID))
+	s.markOverdue(borrowingRecords)
+	s.logger.Log("INFO", "Retrieved borrowing records for member", logging.F("member_id", memberID), logging.F("status", status))
 	return borrowingRecords, nil
 }
 
-func (s *borrowingService) GetAllBorrowingRecords() ([]models.BorrowingRecord, error) {
-	borrowingRecords, err := s.borrowingRepo.GetAll()
+func (s *borrowingService) GetAllBorrowingRecords(filter repositories.BorrowingFilter) ([]models.BorrowingRecord, error) {
+	filter.Now = s.now()
+	borrowingRecords, err := s.borrowingRepo.List(filter)
 	if err != nil {
 		s.logger.Log("ERROR", "Failed to get all borrowing records: "+err.Error())
 		return nil, err
 	}
 
-	s.logger.Log("INFO", "Retrieved all borrowing records")
+	s.markOverdue(borrowingRecords)
+	s.logger.Log("INFO", "Retrieved all borrowing records", logging.F("count", len(borrowingRecords)))
 	return borrowingRecords, nil
 }
+
+func (s *borrowingService) ExportBorrowingRecords(filter repositories.BorrowingFilter, fn func(records []models.BorrowingRecord) error) error {
+	filter.Now = s.now()
+	count := 0
+	err := s.borrowingRepo.EachBatch(filter, exportBatchSize, func(records []models.BorrowingRecord) error {
+		s.markOverdue(records)
+		count += len(records)
+		return fn(records)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to export borrowing records: "+err.Error(), logging.F("count", count))
+		return err
+	}
+	s.logger.Log("INFO", "Exported borrowing records", logging.F("count", count))
+	return nil
+}
+
+// markOverdue fills in the computed Overdue flag.
+func (s *borrowingService) markOverdue(borrowingRecords []models.BorrowingRecord) {
+	now := s.now()
+	for i := range borrowingRecords {
+		borrowingRecords[i].Overdue = borrowingRecords[i].IsOverdue(now)
+	}
+}
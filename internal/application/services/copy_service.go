@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// CopyChange lists the fields of a copy to change; nil fields are kept.
+type CopyChange struct {
+	Status    *string
+	Condition *string
+	Location  *string
+}
+
+type CopyService interface {
+	// AddCopy adds a copy to a book. An empty barcode is generated from the
+	// book ID; an empty status or condition means available and good.
+	AddCopy(bookID uint, bookCopy *models.Copy, actor Actor) error
+	ListCopies(bookID uint) ([]models.Copy, error)
+	// UpdateCopy changes a copy's status, condition or location. Copies on
+	// loan only change status by being returned.
+	UpdateCopy(copyID uint, change CopyChange, actor Actor) (*models.Copy, error)
+}
+
+type copyService struct {
+	copyRepo   repositories.CopyRepository
+	transactor repositories.Transactor
+	holdPolicy HoldPolicy
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewCopyService(copyRepo repositories.CopyRepository, transactor repositories.Transactor, holdPolicy HoldPolicy, logger logging.Logger) CopyService {
+	return &copyService{
+		copyRepo:   copyRepo,
+		transactor: transactor,
+		holdPolicy: holdPolicy,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+func (s *copyService) AddCopy(bookID uint, bookCopy *models.Copy, actor Actor) error {
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByIDForUpdate(bookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+		free, err := freeCopies(tx, book)
+		if err != nil {
+			return err
+		}
+
+		if bookCopy.Status == "" {
+			bookCopy.Status = models.CopyStatusAvailable
+		}
+		if bookCopy.Condition == "" {
+			bookCopy.Condition = models.CopyConditionGood
+		}
+		if bookCopy.Status == models.CopyStatusOnLoan {
+			return &ValidationError{Field: "status", Message: "copies are put on loan by borrowing them"}
+		}
+		if err := validateCopy(bookCopy.Status, bookCopy.Condition); err != nil {
+			return err
+		}
+
+		if bookCopy.Barcode == "" {
+			count, err := tx.Copies.CountForBook(bookID)
+			if err != nil {
+				return fmt.Errorf("failed to count copies: %w", err)
+			}
+			bookCopy.Barcode = models.CopyBarcode(bookID, int(count)+1)
+		}
+		existing, err := tx.Copies.List(repositories.CopyFilter{Barcode: bookCopy.Barcode})
+		if err != nil {
+			return fmt.Errorf("failed to get copies: %w", err)
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("%w: %s", ErrDuplicateBarcode, bookCopy.Barcode)
+		}
+
+		bookCopy.ID = 0
+		bookCopy.BookID = bookID
+		if err := tx.Copies.Create(bookCopy); err != nil {
+			// Another transaction may have taken the barcode since the check
+			if errors.Is(err, repositories.ErrDuplicate) {
+				return fmt.Errorf("%w: %s", ErrDuplicateBarcode, bookCopy.Barcode)
+			}
+			return fmt.Errorf("failed to create copy: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityCopy, bookCopy.ID, nil, bookCopy); err != nil {
+			return err
+		}
+
+		return s.serveHolds(tx, bookID, free)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("book_id", bookID))
+		return err
+	}
+
+	s.logger.Log("INFO", "Copy added", logging.F("book_id", bookID), logging.F("copy_id", bookCopy.ID), logging.F("barcode", bookCopy.Barcode))
+	return nil
+}
+
+func (s *copyService) ListCopies(bookID uint) ([]models.Copy, error) {
+	copies, err := s.copyRepo.List(repositories.CopyFilter{BookID: bookID})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get copies: "+err.Error(), logging.F("book_id", bookID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Retrieved copies", logging.F("book_id", bookID), logging.F("count", len(copies)))
+	return copies, nil
+}
+
+func (s *copyService) UpdateCopy(copyID uint, change CopyChange, actor Actor) (*models.Copy, error) {
+	var updated *models.Copy
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		bookCopy, err := tx.Copies.GetByID(copyID)
+		if err != nil {
+			return fmt.Errorf("failed to get copy by ID: %w", err)
+		}
+		if bookCopy == nil {
+			return ErrCopyNotFound
+		}
+
+		// Lock the book first, then the copy, in the same order as borrowing
+		book, err := tx.Books.GetByIDForUpdate(bookCopy.BookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		var free uint
+		if book != nil {
+			if free, err = freeCopies(tx, book); err != nil {
+				return err
+			}
+		}
+		bookCopy, err = tx.Copies.GetByIDForUpdate(copyID)
+		if err != nil {
+			return fmt.Errorf("failed to get copy by ID: %w", err)
+		}
+		before, err := snapshot(bookCopy)
+		if err != nil {
+			return err
+		}
+
+		if change.Status != nil && *change.Status != bookCopy.Status {
+			if bookCopy.Status == models.CopyStatusOnLoan {
+				return fmt.Errorf("%w: it changes status when it is returned", ErrCopyOnLoan)
+			}
+			if *change.Status == models.CopyStatusOnLoan {
+				return &ValidationError{Field: "status", Message: "copies are put on loan by borrowing them"}
+			}
+			bookCopy.Status = *change.Status
+		}
+		if change.Condition != nil {
+			bookCopy.Condition = *change.Condition
+		}
+		if change.Location != nil {
+			bookCopy.Location = *change.Location
+		}
+		if err := validateCopy(bookCopy.Status, bookCopy.Condition); err != nil {
+			return err
+		}
+
+		if err := tx.Copies.Update(bookCopy); err != nil {
+			return fmt.Errorf("failed to update copy: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionUpdate, models.AuditEntityCopy, bookCopy.ID, before, bookCopy); err != nil {
+			return err
+		}
+		updated = bookCopy
+
+		// A copy back from maintenance may be owed to a member in the queue
+		return s.serveHolds(tx, bookCopy.BookID, free)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("copy_id", copyID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Copy updated", logging.F("copy_id", copyID), logging.F("status", updated.Status), logging.F("condition", updated.Condition))
+	return updated, nil
+}
+
+// serveHolds rereads the book, whose availability may have just changed
+// from free copies, serves its hold queue and announces the book when it has
+// become available. The book must already be locked.
+func (s *copyService) serveHolds(tx repositories.Tx, bookID uint, free uint) error {
+	book, err := tx.Books.GetByID(bookID)
+	if err != nil {
+		return fmt.Errorf("failed to get book by ID: %w", err)
+	}
+	if book == nil {
+		return nil
+	}
+	_, err = serveHoldQueue(tx, book, free, s.now(), s.holdPolicy)
+	return err
+}
+
+func validateCopy(status, condition string) error {
+	if !slices.Contains(models.CopyStatuses, status) {
+		return &ValidationError{Field: "status", Message: "status must be one of " + strings.Join(models.CopyStatuses, ", ")}
+	}
+	if !slices.Contains(models.CopyConditions, condition) {
+		return &ValidationError{Field: "condition", Message: "condition must be one of " + strings.Join(models.CopyConditions, ", ")}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// noBarcodeLookup finds no copies, as if another transaction saved the same
+// barcode between the service's check and its write.
+type noBarcodeLookup struct{ repositories.CopyRepository }
+
+func (noBarcodeLookup) List(repositories.CopyFilter) ([]models.Copy, error) { return nil, nil }
+
+func TestAddCopyReportsConcurrentBarcode(t *testing.T) {
+	ts := newTestStore()
+	book := newTestBook("The Beginning Place", 0)
+	if err := ts.books.Create(book); err != nil {
+		t.Fatalf("Create book: %v", err)
+	}
+	if err := ts.copies.Create(&models.Copy{BookID: book.ID, Barcode: "SHELF-1"}); err != nil {
+		t.Fatalf("Create copy: %v", err)
+	}
+	transactor := txHook{Transactor: ts.transactor, hook: func(tx *repositories.Tx) {
+		tx.Copies = noBarcodeLookup{tx.Copies}
+	}}
+	service := services.NewCopyService(ts.copies, transactor, services.HoldPolicy{PickupWindow: time.Hour}, nopLogger{})
+
+	err := service.AddCopy(book.ID, &models.Copy{Barcode: "SHELF-1"}, services.Actor{UserID: 1})
+	if !errors.Is(err, services.ErrDuplicateBarcode) {
+		t.Errorf("AddCopy: err = %v, want %v", err, services.ErrDuplicateBarcode)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import "errors"
+
+// Errors returned by the services. They may be wrapped with more detail, so
+// compare them with errors.Is.
+var (
+	ErrBookNotFound            = errors.New("book not found")
+	ErrBorrowingRecordNotFound = errors.New("borrowing record not found")
+	ErrHoldNotFound            = errors.New("hold not found")
+	ErrFineNotFound            = errors.New("fine not found")
+	ErrCopyNotFound            = errors.New("copy not found")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrDeliveryNotFound        = errors.New("webhook delivery not found")
+
+	// ErrNotOwner is returned when a member acts on another member's loan
+	// or hold.
+	ErrNotOwner = errors.New("unauthorized")
+
+	ErrUnavailable      = errors.New("book is not available")
+	ErrAlreadyReturned  = errors.New("book is already returned")
+	ErrRenewalRefused   = errors.New("loan cannot be renewed")
+	ErrHoldRefused      = errors.New("hold cannot be placed")
+	ErrHoldClosed       = errors.New("hold is no longer active")
+	ErrFineSettled      = errors.New("fine is already settled")
+	ErrCopyOnLoan       = errors.New("copy is on loan")
+	ErrDuplicateBarcode = errors.New("barcode is already in use")
+	ErrDuplicateISBN    = errors.New("ISBN is already in use")
+	ErrBookOnLoan       = errors.New("book has copies on loan")
+	ErrBookNotDeleted   = errors.New("book is not deleted")
+	ErrUnpaidFines      = errors.New("book has unpaid fines")
+	ErrDeliveryPending  = errors.New("webhook delivery is still pending")
+
+	// ErrVersionMismatch is returned when a client updates a book that has
+	// changed since the client last read it.
+	ErrVersionMismatch = errors.New("book has been modified")
+)
+
+// ValidationError reports input that is well-formed but not acceptable, such
+// as a negative payment. Field names the offending input.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
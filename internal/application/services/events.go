@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hex/internal/application/events"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// bookEventData is the payload of BookCreated and BookAvailable. Copies
+// counts the copies on the shelf.
+type bookEventData struct {
+	BookID uint    `json:"book_id"`
+	Title  string  `json:"title"`
+	Author string  `json:"author"`
+	ISBN   *string `json:"isbn"`
+	Genre  string  `json:"genre"`
+	Copies uint    `json:"copies"`
+}
+
+func newBookEventData(book *models.Book) bookEventData {
+	return bookEventData{
+		BookID: book.ID,
+		Title:  book.Title,
+		Author: book.Author,
+		ISBN:   book.ISBN,
+		Genre:  book.Genre,
+		Copies: book.Availability,
+	}
+}
+
+// loanEventData is the payload of BookBorrowed, BookReturned and
+// LoanOverdue.
+type loanEventData struct {
+	BorrowingRecordID uint       `json:"borrowing_record_id"`
+	BookID            uint       `json:"book_id"`
+	CopyID            *uint      `json:"copy_id"`
+	MemberID          uint       `json:"member_id"`
+	BorrowDate        time.Time  `json:"borrow_date"`
+	DueDate           time.Time  `json:"due_date"`
+	ReturnDate        *time.Time `json:"return_date,omitempty"`
+	// FineCents is what a late return was charged.
+	FineCents int64 `json:"fine_cents,omitempty"`
+}
+
+func newLoanEventData(record *models.BorrowingRecord) loanEventData {
+	return loanEventData{
+		BorrowingRecordID: record.ID,
+		BookID:            record.BookID,
+		CopyID:            record.CopyID,
+		MemberID:          record.MemberID,
+		BorrowDate:        record.BorrowDate,
+		DueDate:           record.DueDate,
+		ReturnDate:        record.ReturnDate,
+	}
+}
+
+// recordEvent queues a domain event in the outbox within the transaction
+// making the change it describes, so the event exists if and only if the
+// change does. The dispatcher publishes it after the commit, and a delivery
+// to each webhook subscribed to its type is queued alongside it.
+func recordEvent(tx repositories.Tx, eventType string, entityID uint, data any, at time.Time) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	event := models.OutboxEvent{
+		Type:          eventType,
+		EntityID:      entityID,
+		Payload:       payload,
+		OccurredAt:    at,
+		NextAttemptAt: at,
+	}
+	if err := tx.Outbox.Create(&event); err != nil {
+		return fmt.Errorf("failed to queue %s event: %w", eventType, err)
+	}
+	return queueDeliveries(tx, event)
+}
+
+// queueDeliveries queues event for every active webhook subscribed to its
+// type. The body is fixed here so that every attempt sends, and signs, the
+// same bytes.
+func queueDeliveries(tx repositories.Tx, event models.OutboxEvent) error {
+	subscriptions, err := tx.Webhooks.List(true)
+	if err != nil {
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(events.NewMessage(event)); err != nil {
+				return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+			}
+		}
+		delivery := models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  event.OccurredAt,
+		}
+		if err := tx.Deliveries.Create(&delivery); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordAvailable queues BookAvailable when a book that had no free copies,
+// free being how many it had before the change, now has one on the shelf
+// that is not set aside for the ready holds.
+func recordAvailable(tx repositories.Tx, book *models.Book, free uint, ready []models.Hold, at time.Time) error {
+	if free > 0 || book.Availability <= uint(len(ready)) {
+		return nil
+	}
+	return recordEvent(tx, models.EventBookAvailable, book.ID, newBookEventData(book), at)
+}
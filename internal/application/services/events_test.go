@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// availableEvents counts the BookAvailable events queued for a book.
+func availableEvents(t *testing.T, ts *testStore, bookID uint) int {
+	t.Helper()
+	events, err := ts.outbox.ListDueForUpdate(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), 1000)
+	if err != nil {
+		t.Fatalf("ListDueForUpdate: %v", err)
+	}
+	count := 0
+	for _, event := range events {
+		if event.Type == models.EventBookAvailable && event.EntityID == bookID {
+			count++
+		}
+	}
+	return count
+}
+
+func TestBookAvailableWhenHoldReleasesCopy(t *testing.T) {
+	release := map[string]func(t *testing.T, holds services.HoldService, hold models.Hold, clock *clock){
+		"cancel": func(t *testing.T, holds services.HoldService, hold models.Hold, _ *clock) {
+			if err := holds.CancelHold(hold.ID, member(hold.MemberID)); err != nil {
+				t.Fatalf("CancelHold: %v", err)
+			}
+		},
+		"expiry": func(t *testing.T, holds services.HoldService, _ models.Hold, clock *clock) {
+			clock.advance(49 * time.Hour)
+			if expired, err := holds.ExpireHolds(); err != nil || expired != 1 {
+				t.Fatalf("ExpireHolds = %d, %v; want 1", expired, err)
+			}
+		},
+	}
+	for name, fn := range release {
+		t.Run(name, func(t *testing.T) {
+			ts, clock := newTestStore(), newClock()
+			policy := services.HoldPolicy{PickupWindow: 48 * time.Hour}
+			holds := services.NewHoldService(ts.holds, ts.transactor, policy, nopLogger{})
+			services.SetClock(holds, clock.now)
+
+			// A one-copy book, held for member 20, comes back from member 10
+			book := newTestBook("The Farthest Shore", 1)
+			if err := ts.books.Create(book); err != nil {
+				t.Fatalf("Create book: %v", err)
+			}
+			if err := ts.copies.Create(&models.Copy{BookID: book.ID, Barcode: "B1-0001"}); err != nil {
+				t.Fatalf("Create copy: %v", err)
+			}
+			borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14}, services.FinePolicy{}, policy, nopLogger{})
+			services.SetClock(borrowings, clock.now)
+			if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+				t.Fatalf("BorrowBook: %v", err)
+			}
+			hold, err := holds.PlaceHold(book.ID, member(20))
+			if err != nil {
+				t.Fatalf("PlaceHold: %v", err)
+			}
+			loans, err := ts.borrowings.List(repositories.BorrowingFilter{MemberID: 10})
+			if err != nil || len(loans) != 1 {
+				t.Fatalf("loans = %d, %v; want 1", len(loans), err)
+			}
+			if err := borrowings.ReturnBook(loans[0].ID, member(10)); err != nil {
+				t.Fatalf("ReturnBook: %v", err)
+			}
+			if got := availableEvents(t, ts, book.ID); got != 0 {
+				t.Fatalf("%d BookAvailable events while the copy is held, want 0", got)
+			}
+
+			fn(t, holds, *hold, clock)
+			if got := availableEvents(t, ts, book.ID); got != 1 {
+				t.Errorf("%d BookAvailable events after the hold let go, want 1", got)
+			}
+		})
+	}
+}
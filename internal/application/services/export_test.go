@@ -0,0 +1,32 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// Internals used by the tests in package services_test.
+const (
+	MaxNewCopies    = maxNewCopies
+	ImportBatchSize = importBatchSize
+)
+
+// SetClock makes service read the time from now.
+func SetClock(service any, now func() time.Time) {
+	switch s := service.(type) {
+	case *BookService:
+		s.now = now
+	case *borrowingService:
+		s.now = now
+	case *copyService:
+		s.now = now
+	case *holdService:
+		s.now = now
+	case *webhookService:
+		s.now = now
+	case *WebhookDispatcher:
+		s.now = now
+	default:
+		panic(fmt.Sprintf("SetClock: %T has no clock", service))
+	}
+}
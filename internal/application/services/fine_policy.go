@@ -0,0 +1,46 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// FinePolicy decides what a late return costs. Amounts are in cents.
+type FinePolicy struct {
+	// DailyRateCents is charged for every day late beyond the grace period.
+	DailyRateCents int64
+	// CapCents limits a single fine; zero means no cap.
+	CapCents int64
+	// GraceDays are free: a book this many days late is not fined, and
+	// later returns are charged only for the days after them.
+	GraceDays int
+	// BlockThresholdCents is the unpaid balance above which a member may not
+	// borrow; a negative value disables the check.
+	BlockThresholdCents int64
+}
+
+// Assess returns how many days late a book returned at returnedAt is, and
+// the fine owed for it. A record without a due date is never late.
+func (p FinePolicy) Assess(dueDate, returnedAt time.Time) (daysLate uint, amountCents int64) {
+	if dueDate.IsZero() || !returnedAt.After(dueDate) {
+		return 0, 0
+	}
+
+	// Any part of a day counts as a full day
+	days := int(math.Ceil(returnedAt.Sub(dueDate).Hours() / 24))
+	chargeable := days - p.GraceDays
+	if chargeable <= 0 {
+		return uint(days), 0
+	}
+
+	amountCents = int64(chargeable) * p.DailyRateCents
+	if p.CapCents > 0 && amountCents > p.CapCents {
+		amountCents = p.CapCents
+	}
+	return uint(days), amountCents
+}
+
+// Blocks reports whether an unpaid balance stops a member from borrowing.
+func (p FinePolicy) Blocks(balanceCents int64) bool {
+	return p.BlockThresholdCents >= 0 && balanceCents > p.BlockThresholdCents
+}
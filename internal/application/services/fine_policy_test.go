@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hex/internal/application/services"
+)
+
+func TestFinePolicyAssess(t *testing.T) {
+	policy := services.FinePolicy{DailyRateCents: 25, CapCents: 500, GraceDays: 2}
+	due := time.Date(2026, 3, 16, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		returned time.Time
+		days     uint
+		cents    int64
+	}{
+		{"on time", due, 0, 0},
+		{"within grace", due.Add(48 * time.Hour), 2, 0},
+		{"part of a day counts", due.Add(48*time.Hour + time.Minute), 3, 25},
+		{"after grace", due.Add(10 * 24 * time.Hour), 10, 200},
+		{"capped", due.Add(60 * 24 * time.Hour), 60, 500},
+	}
+	for _, tt := range tests {
+		days, cents := policy.Assess(due, tt.returned)
+		if days != tt.days || cents != tt.cents {
+			t.Errorf("%s: Assess = %d days, %d cents; want %d, %d", tt.name, days, cents, tt.days, tt.cents)
+		}
+	}
+
+	if days, cents := policy.Assess(time.Time{}, due); days != 0 || cents != 0 {
+		t.Errorf("no due date: Assess = %d days, %d cents; want 0, 0", days, cents)
+	}
+}
+
+func TestFinePolicyBlocks(t *testing.T) {
+	policy := services.FinePolicy{BlockThresholdCents: 1000}
+	if policy.Blocks(1000) || !policy.Blocks(1001) {
+		t.Errorf("threshold 1000 blocks 1000: %v, 1001: %v; want only balances above it blocked", policy.Blocks(1000), policy.Blocks(1001))
+	}
+
+	policy.BlockThresholdCents = -1
+	if policy.Blocks(1 << 40) {
+		t.Error("a negative threshold blocked a member")
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+type FineService interface {
+	// GetMyFines lists a member's fines and what they still owe in total.
+	GetMyFines(memberID uint, status string) ([]models.Fine, int64, error)
+	GetAllFines(filter repositories.FineFilter) ([]models.Fine, error)
+	// RecordPayment applies a payment by a member, taken by the acting staff
+	// member. The fine is marked paid once nothing is left outstanding.
+	RecordPayment(fineID uint, amountCents int64, actor Actor) (*models.Fine, error)
+	WaiveFine(fineID uint, reason string, actor Actor) (*models.Fine, error)
+}
+
+type fineService struct {
+	fineRepo   repositories.FineRepository
+	transactor repositories.Transactor
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewFineService(fineRepo repositories.FineRepository, transactor repositories.Transactor, logger logging.Logger) FineService {
+	return &fineService{
+		fineRepo:   fineRepo,
+		transactor: transactor,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+func (s *fineService) GetMyFines(memberID uint, status string) ([]models.Fine, int64, error) {
+	fines, err := s.fineRepo.List(repositories.FineFilter{MemberID: memberID, Status: status})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get fines by member ID: "+err.Error(), logging.F("member_id", memberID))
+		return nil, 0, err
+	}
+
+	balance, err := s.fineRepo.OutstandingBalance(memberID)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get outstanding balance: "+err.Error(), logging.F("member_id", memberID))
+		return nil, 0, err
+	}
+
+	s.logger.Log("INFO", "Retrieved fines for member", logging.F("member_id", memberID), logging.F("status", status))
+	return fines, balance, nil
+}
+
+func (s *fineService) GetAllFines(filter repositories.FineFilter) ([]models.Fine, error) {
+	fines, err := s.fineRepo.List(filter)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get all fines: "+err.Error())
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Retrieved all fines", logging.F("count", len(fines)))
+	return fines, nil
+}
+
+func (s *fineService) RecordPayment(fineID uint, amountCents int64, actor Actor) (*models.Fine, error) {
+	staffID := actor.UserID
+	if amountCents <= 0 {
+		err := &ValidationError{Field: "amount_cents", Message: "payment amount must be positive"}
+		s.logger.Log("ERROR", err.Error(), logging.F("fine_id", fineID))
+		return nil, err
+	}
+
+	fine, err := s.settle(fineID, actor, models.AuditActionPay, func(fine *models.Fine) error {
+		outstanding := fine.OutstandingCents()
+		if amountCents > outstanding {
+			return &ValidationError{
+				Field:   "amount_cents",
+				Message: fmt.Sprintf("payment of %d cents exceeds the outstanding %d cents", amountCents, outstanding),
+			}
+		}
+
+		fine.PaidCents += amountCents
+		if fine.OutstandingCents() == 0 {
+			now := s.now()
+			fine.Status = models.FineStatusPaid
+			fine.ResolvedBy = staffID
+			fine.ResolvedAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Fine payment recorded", logging.F("fine_id", fineID), logging.F("amount_cents", amountCents), logging.F("staff_id", staffID))
+	return fine, nil
+}
+
+func (s *fineService) WaiveFine(fineID uint, reason string, actor Actor) (*models.Fine, error) {
+	staffID := actor.UserID
+	fine, err := s.settle(fineID, actor, models.AuditActionWaive, func(fine *models.Fine) error {
+		now := s.now()
+		fine.Status = models.FineStatusWaived
+		fine.ResolvedBy = staffID
+		fine.ResolvedAt = &now
+		fine.Note = reason
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Fine waived", logging.F("fine_id", fineID), logging.F("staff_id", staffID))
+	return fine, nil
+}
+
+// settle locks an unpaid fine, lets change update it and saves the result,
+// auditing it as action by actor.
+func (s *fineService) settle(fineID uint, actor Actor, action string, change func(fine *models.Fine) error) (*models.Fine, error) {
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		fine, err := tx.Fines.GetByIDForUpdate(fineID)
+		if err != nil {
+			return fmt.Errorf("failed to get fine by ID: %w", err)
+		}
+		if fine == nil {
+			return ErrFineNotFound
+		}
+		if fine.Status != models.FineStatusUnpaid {
+			return fmt.Errorf("%w: it is %s", ErrFineSettled, fine.Status)
+		}
+
+		before, err := snapshot(fine)
+		if err != nil {
+			return err
+		}
+		if err := change(fine); err != nil {
+			return err
+		}
+		if err := tx.Fines.Update(fine); err != nil {
+			return fmt.Errorf("failed to update fine: %w", err)
+		}
+		return recordAudit(tx, actor, action, models.AuditEntityFine, fine.ID, before, fine)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("fine_id", fineID))
+		return nil, err
+	}
+
+	return s.fineRepo.GetByID(fineID)
+}
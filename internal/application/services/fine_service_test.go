@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+var testFinePolicy = services.FinePolicy{DailyRateCents: 25, CapCents: 500, GraceDays: 2, BlockThresholdCents: 100}
+
+// newFineBorrowings returns a borrowing service charging testFinePolicy on
+// 14-day loans, and a one-copy book for it to lend.
+func newFineBorrowings(t *testing.T, ts *testStore, clock *clock) (services.BorrowingService, *models.Book) {
+	t.Helper()
+	book := newTestBook("The Compass Rose", 1)
+	if err := services.NewBookService(ts.books, ts.transactor, nopLogger{}).CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14}, testFinePolicy, services.HoldPolicy{}, nopLogger{})
+	services.SetClock(borrowings, clock.now)
+	return borrowings, book
+}
+
+func TestReturnBookLateChargesFine(t *testing.T) {
+	ts, clock := newTestStore(), newClock()
+	borrowings, book := newFineBorrowings(t, ts, clock)
+	if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+		t.Fatalf("BorrowBook: %v", err)
+	}
+	loans, err := borrowings.GetMyBorrowings(10, repositories.BorrowingStatusActive)
+	if err != nil || len(loans) != 1 {
+		t.Fatalf("GetMyBorrowings = %d loans, %v; want 1", len(loans), err)
+	}
+
+	clock.advance(19 * 24 * time.Hour) // five days late, two of them free
+	if err := borrowings.ReturnBook(loans[0].ID, member(10)); err != nil {
+		t.Fatalf("ReturnBook: %v", err)
+	}
+
+	fines, err := ts.fines.List(repositories.FineFilter{MemberID: 10})
+	if err != nil || len(fines) != 1 {
+		t.Fatalf("fines = %d, %v; want 1", len(fines), err)
+	}
+	if fine := fines[0]; fine.BorrowingRecordID != loans[0].ID || fine.DaysLate != 5 || fine.AmountCents != 75 || fine.Status != models.FineStatusUnpaid {
+		t.Errorf("fine = record %d, %d days, %d cents, %q; want record %d, 5 days, 75 cents, unpaid", fine.BorrowingRecordID, fine.DaysLate, fine.AmountCents, fine.Status, loans[0].ID)
+	}
+}
+
+func TestBorrowBookRefusedOverUnpaidBalance(t *testing.T) {
+	ts, clock := newTestStore(), newClock()
+	borrowings, book := newFineBorrowings(t, ts, clock)
+	if err := ts.fines.Create(&models.Fine{MemberID: 10, AmountCents: 101, Status: models.FineStatusUnpaid}); err != nil {
+		t.Fatalf("Create fine: %v", err)
+	}
+
+	var violation *services.PolicyViolation
+	if err := borrowings.BorrowBook(book.ID, member(10)); !errors.As(err, &violation) || violation.Code != services.ViolationOutstandingFines {
+		t.Fatalf("BorrowBook: err = %v, want a %s violation", err, services.ViolationOutstandingFines)
+	}
+	if err := borrowings.BorrowBook(book.ID, member(20)); err != nil {
+		t.Errorf("BorrowBook by a member without fines: %v", err)
+	}
+}
+
+func TestRecordPaymentSettlesFine(t *testing.T) {
+	ts := newTestStore()
+	fine := &models.Fine{MemberID: 10, AmountCents: 75, Status: models.FineStatusUnpaid}
+	if err := ts.fines.Create(fine); err != nil {
+		t.Fatalf("Create fine: %v", err)
+	}
+	service := services.NewFineService(ts.fines, ts.transactor, nopLogger{})
+	librarian := services.Actor{UserID: 2, Role: "librarian"}
+
+	var validation *services.ValidationError
+	if _, err := service.RecordPayment(fine.ID, 100, librarian); !errors.As(err, &validation) {
+		t.Fatalf("overpayment: err = %v, want a ValidationError", err)
+	}
+	paid, err := service.RecordPayment(fine.ID, 50, librarian)
+	if err != nil || paid.Status != models.FineStatusUnpaid || paid.OutstandingCents() != 25 {
+		t.Fatalf("RecordPayment(50) = %+v, %v; want 25 cents still unpaid", paid, err)
+	}
+	paid, err = service.RecordPayment(fine.ID, 25, librarian)
+	if err != nil || paid.Status != models.FineStatusPaid || paid.ResolvedBy != 2 {
+		t.Fatalf("RecordPayment(25) = %+v, %v; want paid, resolved by 2", paid, err)
+	}
+	if _, err := service.WaiveFine(fine.ID, "goodwill", librarian); !errors.Is(err, services.ErrFineSettled) {
+		t.Errorf("WaiveFine of a paid fine: err = %v, want %v", err, services.ErrFineSettled)
+	}
+
+	if _, balance, err := service.GetMyFines(10, ""); err != nil || balance != 0 {
+		t.Errorf("GetMyFines balance = %d, %v; want 0", balance, err)
+	}
+}
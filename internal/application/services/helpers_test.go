@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"strconv"
+	"time"
+
+	"hex/internal/adapters/persistence/memory"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+
+	"gorm.io/datatypes"
+)
+
+// nopLogger discards every entry.
+type nopLogger struct{}
+
+func (nopLogger) Log(string, string, ...logging.Field) {}
+
+// testStore is an in-memory store with its repositories, standing in for
+// MySQL in service tests.
+type testStore struct {
+	store      *memory.Store
+	books      repositories.BookRepository
+	copies     repositories.CopyRepository
+	borrowings repositories.BorrowingRepository
+	fines      repositories.FineRepository
+	holds      repositories.HoldRepository
+	audit      repositories.AuditRepository
+	outbox     repositories.OutboxRepository
+	webhooks   repositories.WebhookRepository
+	deliveries repositories.WebhookDeliveryRepository
+	transactor repositories.Transactor
+}
+
+func newTestStore() *testStore {
+	store := memory.NewStore()
+	return &testStore{
+		store:      store,
+		books:      memory.NewBookRepository(store),
+		copies:     memory.NewCopyRepository(store),
+		borrowings: memory.NewBorrowingRepository(store),
+		fines:      memory.NewFineRepository(store),
+		holds:      memory.NewHoldRepository(store),
+		audit:      memory.NewAuditRepository(store),
+		outbox:     memory.NewOutboxRepository(store),
+		webhooks:   memory.NewWebhookRepository(store),
+		deliveries: memory.NewWebhookDeliveryRepository(store),
+		transactor: memory.NewTransactor(store),
+	}
+}
+
+// clock is a time the test moves, handed to services through SetClock.
+type clock struct{ time.Time }
+
+func newClock() *clock {
+	return &clock{time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) now() time.Time { return c.Time }
+
+func (c *clock) advance(d time.Duration) { c.Time = c.Time.Add(d) }
+
+// txHook lets a test change the repositories of every transaction before
+// the service sees them.
+type txHook struct {
+	repositories.Transactor
+	hook func(tx *repositories.Tx)
+}
+
+func (t txHook) WithinTransaction(fn func(tx repositories.Tx) error) error {
+	return t.Transactor.WithinTransaction(func(tx repositories.Tx) error {
+		t.hook(&tx)
+		return fn(tx)
+	})
+}
+
+func newTestBook(title string, availability uint) *models.Book {
+	return &models.Book{
+		Title:           title,
+		Author:          "Ursula K. Le Guin",
+		Genre:           "Fiction",
+		PublicationDate: datatypes.Date(time.Date(1969, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Availability:    availability,
+	}
+}
+
+func idOf(book *models.Book) string {
+	return strconv.FormatUint(uint64(book.ID), 10)
+}
+
+func member(id uint) services.Actor {
+	return services.Actor{UserID: id, Role: "member"}
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// HoldPolicy controls the hold queue.
+type HoldPolicy struct {
+	// PickupWindow is how long a copy set aside for a hold waits before it
+	// passes to the next member in the queue.
+	PickupWindow time.Duration
+}
+
+var activeHoldStatuses = []string{models.HoldStatusWaiting, models.HoldStatusReady}
+
+type HoldService interface {
+	// PlaceHold queues the actor for a book; CancelHold cancels one of the
+	// actor's own holds.
+	PlaceHold(bookID uint, actor Actor) (*models.Hold, error)
+	CancelHold(holdID uint, actor Actor) error
+	// GetMyHolds lists a member's active holds with their queue positions.
+	GetMyHolds(memberID uint) ([]models.Hold, error)
+	// GetBookHolds lists the active queue for a book, first in line first.
+	GetBookHolds(bookID uint) ([]models.Hold, error)
+	// ExpireHolds expires ready holds whose pickup window has passed and
+	// sets their copies aside for the next members in line, returning how
+	// many holds it expired. It is meant to be called periodically.
+	ExpireHolds() (int, error)
+}
+
+type holdService struct {
+	holdRepo   repositories.HoldRepository
+	transactor repositories.Transactor
+	holdPolicy HoldPolicy
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewHoldService(holdRepo repositories.HoldRepository, transactor repositories.Transactor, holdPolicy HoldPolicy, logger logging.Logger) HoldService {
+	return &holdService{
+		holdRepo:   holdRepo,
+		transactor: transactor,
+		holdPolicy: holdPolicy,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+func (s *holdService) PlaceHold(bookID uint, actor Actor) (*models.Hold, error) {
+	memberID := actor.UserID
+	var hold *models.Hold
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByIDForUpdate(bookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+
+		existing, err := tx.Holds.List(repositories.HoldFilter{BookID: bookID, MemberID: memberID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("%w: you already have a hold on this book", ErrHoldRefused)
+		}
+
+		loans, err := tx.Borrowings.List(repositories.BorrowingFilter{BookID: bookID, MemberID: memberID, Status: repositories.BorrowingStatusActive})
+		if err != nil {
+			return fmt.Errorf("failed to get borrowing records: %w", err)
+		}
+		if len(loans) > 0 {
+			return fmt.Errorf("%w: you already have this book on loan", ErrHoldRefused)
+		}
+
+		// Holds are for books nobody can take right now
+		free, err := freeCopies(tx, book)
+		if err != nil {
+			return err
+		}
+		ready, err := serveHoldQueue(tx, book, free, s.now(), s.holdPolicy)
+		if err != nil {
+			return err
+		}
+		if book.Availability > uint(len(ready)) {
+			return fmt.Errorf("%w: book is available, borrow it instead", ErrHoldRefused)
+		}
+
+		hold = &models.Hold{BookID: bookID, MemberID: memberID, Status: models.HoldStatusWaiting}
+		if err := tx.Holds.Create(hold); err != nil {
+			return fmt.Errorf("failed to create hold: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityHold, hold.ID, nil, hold); err != nil {
+			return err
+		}
+
+		placed := []models.Hold{*hold}
+		if err := assignQueuePositions(tx.Holds, placed); err != nil {
+			return err
+		}
+		hold.Book = *book
+		hold.Position = placed[0].Position
+		return nil
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("book_id", bookID), logging.F("member_id", memberID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Hold placed", logging.F("hold_id", hold.ID), logging.F("book_id", bookID), logging.F("member_id", memberID), logging.F("position", hold.Position))
+	return hold, nil
+}
+
+func (s *holdService) CancelHold(holdID uint, actor Actor) error {
+	memberID := actor.UserID
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		hold, err := tx.Holds.GetByID(holdID)
+		if err != nil {
+			return fmt.Errorf("failed to get hold by ID: %w", err)
+		}
+		if hold == nil {
+			return ErrHoldNotFound
+		}
+		if hold.MemberID != memberID {
+			return fmt.Errorf("%w: you can only cancel your own holds", ErrNotOwner)
+		}
+
+		// Lock the book first, then the hold, in the same order as borrowing
+		book, err := tx.Books.GetByIDForUpdate(hold.BookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		hold, err = tx.Holds.GetByIDForUpdate(holdID)
+		if err != nil {
+			return fmt.Errorf("failed to get hold by ID: %w", err)
+		}
+		if !hold.IsActive() {
+			return fmt.Errorf("%w: it is %s", ErrHoldClosed, hold.Status)
+		}
+
+		before, err := snapshot(hold)
+		if err != nil {
+			return err
+		}
+		var free uint
+		if book != nil {
+			if free, err = freeCopies(tx, book); err != nil {
+				return err
+			}
+		}
+		hold.Status = models.HoldStatusCancelled
+		if err := tx.Holds.Update(hold); err != nil {
+			return fmt.Errorf("failed to update hold: %w", err)
+		}
+		if err := recordAudit(tx, actor, models.AuditActionCancel, models.AuditEntityHold, hold.ID, before, hold); err != nil {
+			return err
+		}
+
+		// A copy set aside for this hold goes to the next member in line, or
+		// back on the shelf
+		if book != nil {
+			if _, err := serveHoldQueue(tx, book, free, s.now(), s.holdPolicy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("hold_id", holdID), logging.F("member_id", memberID))
+		return err
+	}
+
+	s.logger.Log("INFO", "Hold cancelled", logging.F("hold_id", holdID), logging.F("member_id", memberID))
+	return nil
+}
+
+// GetMyHolds only reads. A hold past its pickup window stays ready until
+// ExpireHolds next runs.
+func (s *holdService) GetMyHolds(memberID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		var err error
+		holds, err = tx.Holds.List(repositories.HoldFilter{MemberID: memberID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		return assignQueuePositions(tx.Holds, holds)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("member_id", memberID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Retrieved holds for member", logging.F("member_id", memberID))
+	return holds, nil
+}
+
+func (s *holdService) GetBookHolds(bookID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		book, err := tx.Books.GetByID(bookID)
+		if err != nil {
+			return fmt.Errorf("failed to get book by ID: %w", err)
+		}
+		if book == nil {
+			return ErrBookNotFound
+		}
+
+		holds, err = tx.Holds.List(repositories.HoldFilter{BookID: bookID, Statuses: activeHoldStatuses})
+		if err != nil {
+			return fmt.Errorf("failed to get holds: %w", err)
+		}
+		return assignQueuePositions(tx.Holds, holds)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("book_id", bookID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Retrieved hold queue for book", logging.F("book_id", bookID), logging.F("count", len(holds)))
+	return holds, nil
+}
+
+func (s *holdService) ExpireHolds() (int, error) {
+	now := s.now()
+	ready, err := s.holdRepo.List(repositories.HoldFilter{Statuses: []string{models.HoldStatusReady}})
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get ready holds: "+err.Error())
+		return 0, err
+	}
+
+	// Serve each book's queue once, however many of its holds lapsed
+	var bookIDs []uint
+	lapsed := map[uint]bool{}
+	for _, hold := range ready {
+		if isLapsed(hold, now) && !lapsed[hold.BookID] {
+			lapsed[hold.BookID] = true
+			bookIDs = append(bookIDs, hold.BookID)
+		}
+	}
+
+	expired := 0
+	for _, bookID := range bookIDs {
+		count := 0
+		err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+			book, err := tx.Books.GetByIDForUpdate(bookID)
+			if err != nil {
+				return fmt.Errorf("failed to get book by ID: %w", err)
+			}
+			if book == nil {
+				return nil
+			}
+
+			// Recount under lock in case a hold was collected or cancelled since
+			holds, err := tx.Holds.List(repositories.HoldFilter{BookID: bookID, Statuses: []string{models.HoldStatusReady}})
+			if err != nil {
+				return fmt.Errorf("failed to get holds: %w", err)
+			}
+			for _, hold := range holds {
+				if isLapsed(hold, now) {
+					count++
+				}
+			}
+			_, err = serveHoldQueue(tx, book, saturatingSub(book.Availability, uint(len(holds))), now, s.holdPolicy)
+			return err
+		})
+		if err != nil {
+			s.logger.Log("ERROR", "Failed to expire holds: "+err.Error(), logging.F("book_id", bookID))
+			return expired, err
+		}
+		expired += count
+	}
+
+	if expired > 0 {
+		s.logger.Log("INFO", "Expired holds", logging.F("count", expired))
+	}
+	return expired, nil
+}
+
+// isLapsed reports whether a ready hold's pickup window has passed.
+func isLapsed(hold models.Hold, now time.Time) bool {
+	return hold.Status == models.HoldStatusReady && hold.ExpiresAt != nil && !now.Before(*hold.ExpiresAt)
+}
+
+// serveHoldQueue brings the queue for book up to date. Ready holds past their
+// pickup window expire, then copies on the shelf that are not yet set aside
+// go to the members waiting longest. free is how many copies freeCopies
+// counted before the caller changed anything; BookAvailable is queued when
+// that was none and some are free now. It returns the holds now ready. book
+// must be locked by the caller and carry the current availability.
+func serveHoldQueue(tx repositories.Tx, book *models.Book, free uint, now time.Time, policy HoldPolicy) ([]models.Hold, error) {
+	holds, err := tx.Holds.List(repositories.HoldFilter{BookID: book.ID, Statuses: activeHoldStatuses})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+
+	var ready, waiting []models.Hold
+	for _, hold := range holds {
+		if hold.Status == models.HoldStatusWaiting {
+			waiting = append(waiting, hold)
+			continue
+		}
+		if isLapsed(hold, now) {
+			hold.Status = models.HoldStatusExpired
+			if err := tx.Holds.Update(&hold); err != nil {
+				return nil, fmt.Errorf("failed to expire hold: %w", err)
+			}
+			continue
+		}
+		ready = append(ready, hold)
+	}
+
+	for book.Availability > uint(len(ready)) && len(waiting) > 0 {
+		hold := waiting[0]
+		waiting = waiting[1:]
+
+		readyAt := now
+		expiresAt := now.Add(policy.PickupWindow)
+		hold.Status = models.HoldStatusReady
+		hold.ReadyAt = &readyAt
+		hold.ExpiresAt = &expiresAt
+		if err := tx.Holds.Update(&hold); err != nil {
+			return nil, fmt.Errorf("failed to mark hold ready: %w", err)
+		}
+		ready = append(ready, hold)
+	}
+
+	if err := recordAvailable(tx, book, free, ready, now); err != nil {
+		return nil, err
+	}
+	return ready, nil
+}
+
+// freeCopies counts the copies of book on the shelf that are not set aside
+// for a ready hold, lapsed or not.
+func freeCopies(tx repositories.Tx, book *models.Book) (uint, error) {
+	ready, err := tx.Holds.List(repositories.HoldFilter{BookID: book.ID, Statuses: []string{models.HoldStatusReady}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get holds: %w", err)
+	}
+	return saturatingSub(book.Availability, uint(len(ready))), nil
+}
+
+// saturatingSub returns a-b, or 0 when b is larger.
+func saturatingSub(a, b uint) uint {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+// assignQueuePositions fills in Position for each hold: 0 when ready,
+// otherwise its place among the holds still waiting for the same book.
+func assignQueuePositions(repo repositories.HoldRepository, holds []models.Hold) error {
+	queues := map[uint][]models.Hold{}
+	for i := range holds {
+		hold := &holds[i]
+		if hold.Status == models.HoldStatusWaiting {
+			queue, ok := queues[hold.BookID]
+			if !ok {
+				var err error
+				queue, err = repo.List(repositories.HoldFilter{BookID: hold.BookID, Statuses: []string{models.HoldStatusWaiting}})
+				if err != nil {
+					return fmt.Errorf("failed to get hold queue: %w", err)
+				}
+				queues[hold.BookID] = queue
+			}
+			for position, queued := range queue {
+				if queued.ID == hold.ID {
+					hold.Position = position + 1
+					break
+				}
+			}
+		} else {
+			hold.Position = 0
+		}
+	}
+	return nil
+}
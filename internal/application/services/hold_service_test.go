@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// holdFixture is a one-copy book on loan to a member, with services sharing
+// a clock the test moves.
+type holdFixture struct {
+	ts         *testStore
+	borrowings services.BorrowingService
+	holds      services.HoldService
+	book       *models.Book
+	clock      *clock
+}
+
+func newHoldFixture(t *testing.T) *holdFixture {
+	t.Helper()
+	f := &holdFixture{ts: newTestStore(), clock: newClock()}
+	policy := services.HoldPolicy{PickupWindow: 48 * time.Hour}
+
+	books := services.NewBookService(f.ts.books, f.ts.transactor, nopLogger{})
+	services.SetClock(books, f.clock.now)
+	f.borrowings = services.NewBorrowingService(f.ts.borrowings, f.ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14}, services.FinePolicy{}, policy, nopLogger{})
+	services.SetClock(f.borrowings, f.clock.now)
+	f.holds = services.NewHoldService(f.ts.holds, f.ts.transactor, policy, nopLogger{})
+	services.SetClock(f.holds, f.clock.now)
+
+	f.book = newTestBook("A Wizard of Earthsea", 1)
+	if err := books.CreateBook(f.book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	if err := f.borrowings.BorrowBook(f.book.ID, member(10)); err != nil {
+		t.Fatalf("BorrowBook: %v", err)
+	}
+	return f
+}
+
+// returnLoan returns the member's only loan of the fixture's book.
+func (f *holdFixture) returnLoan(t *testing.T, memberID uint) {
+	t.Helper()
+	loans, err := f.borrowings.GetMyBorrowings(memberID, repositories.BorrowingStatusActive)
+	if err != nil || len(loans) != 1 {
+		t.Fatalf("GetMyBorrowings(%d) = %d loans, %v; want 1", memberID, len(loans), err)
+	}
+	if err := f.borrowings.ReturnBook(loans[0].ID, member(memberID)); err != nil {
+		t.Fatalf("ReturnBook: %v", err)
+	}
+}
+
+func (f *holdFixture) holdOf(t *testing.T, memberID uint) models.Hold {
+	t.Helper()
+	holds, err := f.ts.holds.List(repositories.HoldFilter{BookID: f.book.ID, MemberID: memberID})
+	if err != nil || len(holds) != 1 {
+		t.Fatalf("holds of member %d = %d, %v; want 1", memberID, len(holds), err)
+	}
+	return holds[0]
+}
+
+func TestExpireHoldsPassesCopyOn(t *testing.T) {
+	f := newHoldFixture(t)
+	for _, id := range []uint{20, 30} {
+		if _, err := f.holds.PlaceHold(f.book.ID, member(id)); err != nil {
+			t.Fatalf("PlaceHold(%d): %v", id, err)
+		}
+	}
+	f.returnLoan(t, 10)
+	if hold := f.holdOf(t, 20); hold.Status != models.HoldStatusReady {
+		t.Fatalf("first hold is %q after the return, want ready", hold.Status)
+	}
+
+	// Reading holds past the pickup window changes nothing
+	f.clock.advance(49 * time.Hour)
+	holds, err := f.holds.GetMyHolds(20)
+	if err != nil || len(holds) != 1 || holds[0].Status != models.HoldStatusReady {
+		t.Fatalf("GetMyHolds = %+v, %v; want the lapsed hold still ready", holds, err)
+	}
+	if _, err := f.holds.GetBookHolds(f.book.ID); err != nil {
+		t.Fatalf("GetBookHolds: %v", err)
+	}
+	if hold := f.holdOf(t, 30); hold.Status != models.HoldStatusWaiting {
+		t.Fatalf("second hold is %q after reads, want waiting", hold.Status)
+	}
+
+	expired, err := f.holds.ExpireHolds()
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireHolds = %d, %v; want 1", expired, err)
+	}
+	if hold := f.holdOf(t, 20); hold.Status != models.HoldStatusExpired {
+		t.Errorf("lapsed hold is %q, want expired", hold.Status)
+	}
+	hold := f.holdOf(t, 30)
+	if hold.Status != models.HoldStatusReady || hold.ExpiresAt == nil || !hold.ExpiresAt.Equal(f.clock.Add(48*time.Hour)) {
+		t.Errorf("next hold is %q until %v, want ready for a fresh window", hold.Status, hold.ExpiresAt)
+	}
+
+	if expired, err := f.holds.ExpireHolds(); err != nil || expired != 0 {
+		t.Errorf("second ExpireHolds = %d, %v; want 0", expired, err)
+	}
+}
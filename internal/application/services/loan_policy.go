@@ -0,0 +1,38 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"hex/pkg/models"
+)
+
+// LoanPolicy decides how long a book may be kept and how often a loan may be
+// extended.
+type LoanPolicy struct {
+	// DefaultDays is the loan period for books without a more specific one.
+	DefaultDays int
+	// GenreDays overrides DefaultDays per genre. Keys are matched ignoring case.
+	GenreDays map[string]int
+	// MaxRenewals is how many times a single loan may be renewed.
+	MaxRenewals uint
+}
+
+// LoanDays returns the loan period for book: its own LoanPeriodDays when set,
+// then its genre's period, then the default.
+func (p LoanPolicy) LoanDays(book models.Book) int {
+	if book.LoanPeriodDays > 0 {
+		return int(book.LoanPeriodDays)
+	}
+	for genre, days := range p.GenreDays {
+		if strings.EqualFold(genre, book.Genre) {
+			return days
+		}
+	}
+	return p.DefaultDays
+}
+
+// DueDate returns when a loan of book starting at from must be returned.
+func (p LoanPolicy) DueDate(book models.Book, from time.Time) time.Time {
+	return from.AddDate(0, 0, p.LoanDays(book))
+}
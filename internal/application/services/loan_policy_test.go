@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+)
+
+func TestLoanPolicyLoanDays(t *testing.T) {
+	policy := services.LoanPolicy{DefaultDays: 21, GenreDays: map[string]int{"Reference": 7}}
+
+	book := newTestBook("Always Coming Home", 1)
+	if days := policy.LoanDays(*book); days != 21 {
+		t.Errorf("fiction loan days = %d, want the default 21", days)
+	}
+	book.Genre = "reference"
+	if days := policy.LoanDays(*book); days != 7 {
+		t.Errorf("reference loan days = %d, want the genre's 7", days)
+	}
+	book.LoanPeriodDays = 2
+	if days := policy.LoanDays(*book); days != 2 {
+		t.Errorf("loan days = %d, want the book's own 2", days)
+	}
+}
+
+func TestRenewBorrowingExtendsFromDueDate(t *testing.T) {
+	ts, clock := newTestStore(), newClock()
+	book := newTestBook("The Lathe of Heaven", 1)
+	if err := services.NewBookService(ts.books, ts.transactor, nopLogger{}).CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14, MaxRenewals: 1}, services.FinePolicy{}, services.HoldPolicy{}, nopLogger{})
+	services.SetClock(borrowings, clock.now)
+	if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+		t.Fatalf("BorrowBook: %v", err)
+	}
+	loans, err := borrowings.GetMyBorrowings(10, repositories.BorrowingStatusActive)
+	if err != nil || len(loans) != 1 {
+		t.Fatalf("GetMyBorrowings = %d loans, %v; want 1", len(loans), err)
+	}
+	due := loans[0].DueDate
+	if want := clock.AddDate(0, 0, 14); !due.Equal(want) {
+		t.Fatalf("due %v, want %v", due, want)
+	}
+
+	if _, err := borrowings.RenewBorrowing(loans[0].ID, member(20)); !errors.Is(err, services.ErrNotOwner) {
+		t.Errorf("RenewBorrowing by another member: err = %v, want %v", err, services.ErrNotOwner)
+	}
+	clock.advance(10 * 24 * time.Hour)
+	renewed, err := borrowings.RenewBorrowing(loans[0].ID, member(10))
+	if err != nil {
+		t.Fatalf("RenewBorrowing: %v", err)
+	}
+	if !renewed.DueDate.Equal(due.AddDate(0, 0, 14)) || renewed.RenewalCount != 1 {
+		t.Errorf("renewed loan due %v after %d renewals, want %v after 1", renewed.DueDate, renewed.RenewalCount, due.AddDate(0, 0, 14))
+	}
+	if _, err := borrowings.RenewBorrowing(loans[0].ID, member(10)); !errors.Is(err, services.ErrRenewalRefused) {
+		t.Errorf("renewing past MaxRenewals: err = %v, want %v", err, services.ErrRenewalRefused)
+	}
+}
+
+func TestGetMyBorrowingsOverdue(t *testing.T) {
+	ts, clock := newTestStore(), newClock()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	borrowings := services.NewBorrowingService(ts.borrowings, ts.transactor, services.BorrowingPolicy{}, services.LoanPolicy{DefaultDays: 14, GenreDays: map[string]int{"Reference": 7}}, services.FinePolicy{}, services.HoldPolicy{}, nopLogger{})
+	services.SetClock(borrowings, clock.now)
+	for _, genre := range []string{"Fiction", "Reference"} {
+		book := newTestBook(genre+" of the World", 1)
+		book.Genre = genre
+		if err := books.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+			t.Fatalf("CreateBook: %v", err)
+		}
+		if err := borrowings.BorrowBook(book.ID, member(10)); err != nil {
+			t.Fatalf("BorrowBook %s: %v", genre, err)
+		}
+	}
+
+	clock.advance(8 * 24 * time.Hour)
+	overdue, err := borrowings.GetMyBorrowings(10, repositories.BorrowingStatusOverdue)
+	if err != nil || len(overdue) != 1 || overdue[0].Book.Genre != "Reference" || !overdue[0].Overdue {
+		t.Fatalf("overdue loans = %+v, %v; want the reference book, flagged", overdue, err)
+	}
+	active, err := borrowings.GetMyBorrowings(10, repositories.BorrowingStatusActive)
+	if err != nil || len(active) != 2 {
+		t.Errorf("active loans = %d, %v; want both, overdue included", len(active), err)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hex/internal/application/events"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// maxEventError caps the error kept on an event or webhook delivery that
+// failed.
+const maxEventError = 1024
+
+// truncateError is the message of err cut to maxEventError bytes.
+func truncateError(err error) string {
+	message := err.Error()
+	if len(message) > maxEventError {
+		message = message[:maxEventError]
+	}
+	return message
+}
+
+// OutboxPolicy controls how the dispatcher relays the outbox.
+type OutboxPolicy struct {
+	// PollInterval is how long the dispatcher waits when nothing is due.
+	PollInterval time.Duration
+	// BatchSize is how many events are claimed at a time.
+	BatchSize int
+	// Lease is how long claimed events are left to their dispatcher. Should
+	// it stop part way, the events are retried once the lease runs out.
+	Lease time.Duration
+	// RetryBase is the wait before the first retry. Each retry after that
+	// waits twice as long as the one before, up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// RetryDelay is how long to wait before publishing an event again after
+// attempts failed tries.
+func (p OutboxPolicy) RetryDelay(attempts uint) time.Duration {
+	return backoff(p.RetryBase, p.RetryMax, attempts)
+}
+
+// backoff doubles base for each failed attempt after the first, up to
+// ceiling unless ceiling is 0.
+func backoff(base, ceiling time.Duration, attempts uint) time.Duration {
+	delay := base
+	for i := uint(1); i < attempts && (ceiling <= 0 || delay < ceiling); i++ {
+		delay *= 2
+	}
+	if ceiling > 0 {
+		delay = min(delay, ceiling)
+	}
+	return delay
+}
+
+// OutboxDispatcher relays events from the outbox to a publisher. Events are
+// retried until the publisher accepts them, so each is delivered at least
+// once; one that keeps failing does not hold up those queued after it.
+// Several dispatchers may share an outbox, each claiming its own events.
+type OutboxDispatcher struct {
+	outboxRepo repositories.OutboxRepository
+	transactor repositories.Transactor
+	publisher  events.Publisher
+	policy     OutboxPolicy
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewOutboxDispatcher(outboxRepo repositories.OutboxRepository, transactor repositories.Transactor, publisher events.Publisher, policy OutboxPolicy, logger logging.Logger) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxRepo: outboxRepo,
+		transactor: transactor,
+		publisher:  publisher,
+		policy:     policy,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Run relays events until ctx is done. After a full batch it carries on
+// straight away; otherwise it waits PollInterval before looking again.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	for {
+		claimed, err := d.DispatchDue(ctx)
+		if err == nil && claimed == d.policy.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.policy.PollInterval):
+		}
+	}
+}
+
+// DispatchDue claims one batch of due events and publishes them, returning
+// how many were claimed. Failed events are scheduled for a retry. Events
+// left unpublished because ctx ended are retried when their lease runs out.
+func (d *OutboxDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	var claimed []models.OutboxEvent
+	err := d.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		now := d.now()
+		due, err := tx.Outbox.ListDueForUpdate(now, d.policy.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get due events: %w", err)
+		}
+		for i := range due {
+			due[i].Attempts++
+			due[i].NextAttemptAt = now.Add(d.policy.Lease)
+			if err := tx.Outbox.Update(&due[i]); err != nil {
+				return fmt.Errorf("failed to claim event: %w", err)
+			}
+		}
+		claimed = due
+		return nil
+	})
+	if err != nil {
+		d.logger.Log("ERROR", "Failed to claim outbox events: "+err.Error())
+		return 0, err
+	}
+
+	for _, event := range claimed {
+		if ctx.Err() != nil {
+			break
+		}
+		d.publish(ctx, event)
+	}
+	return len(claimed), nil
+}
+
+// publish hands one claimed event to the publisher and records the outcome.
+// An event published but not marked so is published again after its lease,
+// which at-least-once delivery allows.
+func (d *OutboxDispatcher) publish(ctx context.Context, event models.OutboxEvent) {
+	err := d.publisher.Publish(ctx, events.NewMessage(event))
+	now := d.now()
+	if err != nil {
+		retryAt := now.Add(d.policy.RetryDelay(event.Attempts))
+		event.NextAttemptAt = retryAt
+		event.LastError = truncateError(err)
+		d.logger.Log("WARN", "Failed to publish event: "+err.Error(),
+			logging.F("event_id", event.ID), logging.F("type", event.Type),
+			logging.F("attempts", event.Attempts), logging.F("retry_at", retryAt))
+	} else {
+		event.PublishedAt = &now
+		event.LastError = ""
+	}
+
+	if err := d.outboxRepo.Update(&event); err != nil {
+		d.logger.Log("ERROR", "Failed to record event delivery: "+err.Error(), logging.F("event_id", event.ID))
+		return
+	}
+	if event.PublishedAt != nil {
+		d.logger.Log("INFO", "Event published", logging.F("event_id", event.ID), logging.F("type", event.Type), logging.F("attempts", event.Attempts))
+	}
+}
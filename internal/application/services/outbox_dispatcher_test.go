@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hex/internal/application/events"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+// flakyPublisher refuses the first failures messages and keeps the rest.
+type flakyPublisher struct {
+	failures  int
+	published []events.Message
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, message events.Message) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, message)
+	return nil
+}
+
+func TestOutboxDispatcherRetriesUntilPublished(t *testing.T) {
+	ts := newTestStore()
+	books := services.NewBookService(ts.books, ts.transactor, nopLogger{})
+	book := newTestBook("The Other Wind", 1)
+	if err := books.CreateBook(book, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+
+	publisher := &flakyPublisher{failures: 1}
+	dispatcher := services.NewOutboxDispatcher(ts.outbox, ts.transactor, publisher, services.OutboxPolicy{BatchSize: 10, Lease: time.Hour}, nopLogger{})
+
+	// The first attempt fails and, with no retry delay, is due again at once
+	if claimed, err := dispatcher.DispatchDue(context.Background()); err != nil || claimed != 1 || len(publisher.published) != 0 {
+		t.Fatalf("first DispatchDue = %d, %v with %d published; want 1 claimed, none published", claimed, err, len(publisher.published))
+	}
+	if claimed, err := dispatcher.DispatchDue(context.Background()); err != nil || claimed != 1 {
+		t.Fatalf("second DispatchDue = %d, %v; want the event retried", claimed, err)
+	}
+	if len(publisher.published) != 1 || publisher.published[0].Type != models.EventBookCreated {
+		t.Fatalf("published %+v, want one BookCreated", publisher.published)
+	}
+
+	if claimed, err := dispatcher.DispatchDue(context.Background()); err != nil || claimed != 0 {
+		t.Errorf("DispatchDue after publishing = %d, %v; want nothing left", claimed, err)
+	}
+}
+
+func TestOutboxPolicyRetryDelay(t *testing.T) {
+	capped := services.OutboxPolicy{RetryBase: time.Second, RetryMax: 10 * time.Second}
+	uncapped := services.OutboxPolicy{RetryBase: time.Second}
+	tests := []struct {
+		policy   services.OutboxPolicy
+		attempts uint
+		want     time.Duration
+	}{
+		{capped, 1, time.Second},
+		{capped, 2, 2 * time.Second},
+		{capped, 3, 4 * time.Second},
+		{capped, 5, 10 * time.Second},
+		{uncapped, 5, 16 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.RetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RetryDelay(%d) with max %v = %v, want %v", tt.attempts, tt.policy.RetryMax, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hex/internal/application/events"
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// WebhookPolicy controls how the dispatcher delivers webhooks.
+type WebhookPolicy struct {
+	// PollInterval is how long the dispatcher waits when nothing is due.
+	PollInterval time.Duration
+	// BatchSize is how many deliveries are claimed at a time. They are sent
+	// one after the other, so Lease should cover a batch of timeouts.
+	BatchSize int
+	// Lease is how long claimed deliveries are left to their dispatcher.
+	// Should it stop part way, they are tried again once it runs out.
+	Lease time.Duration
+	// RetryBase is the wait before the first retry. Each retry after that
+	// waits twice as long as the one before, up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// MaxAttempts is how many times a delivery is tried before it is
+	// dead-lettered.
+	MaxAttempts uint
+}
+
+// RetryDelay is how long to wait before trying a delivery again after
+// attempts failed tries.
+func (p WebhookPolicy) RetryDelay(attempts uint) time.Duration {
+	return backoff(p.RetryBase, p.RetryMax, attempts)
+}
+
+// WebhookDispatcher sends queued webhook deliveries to their subscriptions.
+// A failed delivery is retried with exponential backoff and marked dead
+// once MaxAttempts tries have failed; one that keeps failing does not hold
+// up those queued after it. Several dispatchers may share the queue, each
+// claiming its own deliveries.
+type WebhookDispatcher struct {
+	deliveryRepo repositories.WebhookDeliveryRepository
+	transactor   repositories.Transactor
+	sender       events.WebhookSender
+	policy       WebhookPolicy
+	logger       logging.Logger
+	now          func() time.Time
+}
+
+func NewWebhookDispatcher(deliveryRepo repositories.WebhookDeliveryRepository, transactor repositories.Transactor, sender events.WebhookSender, policy WebhookPolicy, logger logging.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		deliveryRepo: deliveryRepo,
+		transactor:   transactor,
+		sender:       sender,
+		policy:       policy,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Run delivers webhooks until ctx is done. After a full batch it carries on
+// straight away; otherwise it waits PollInterval before looking again.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	for {
+		claimed, err := d.DispatchDue(ctx)
+		if err == nil && claimed == d.policy.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.policy.PollInterval):
+		}
+	}
+}
+
+// claimedDelivery is a delivery claimed by a dispatcher together with the
+// subscription it goes to.
+type claimedDelivery struct {
+	delivery     models.WebhookDelivery
+	subscription models.WebhookSubscription
+}
+
+// DispatchDue claims one batch of due deliveries and sends them, returning
+// how many were claimed. Deliveries whose subscription has since been
+// deleted or paused are cancelled instead. Deliveries left unsent because
+// ctx ended are tried again when their lease runs out.
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	var claimed []claimedDelivery
+	count := 0
+	err := d.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		now := d.now()
+		due, err := tx.Deliveries.ListDueForUpdate(now, d.policy.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get due webhook deliveries: %w", err)
+		}
+		count = len(due)
+
+		subscriptions := map[uint]*models.WebhookSubscription{}
+		for i := range due {
+			delivery := &due[i]
+			subscription, ok := subscriptions[delivery.SubscriptionID]
+			if !ok {
+				if subscription, err = tx.Webhooks.GetByID(delivery.SubscriptionID); err != nil {
+					return fmt.Errorf("failed to get webhook by ID: %w", err)
+				}
+				subscriptions[delivery.SubscriptionID] = subscription
+			}
+
+			switch {
+			case subscription == nil:
+				delivery.Status = models.WebhookDeliveryCancelled
+				delivery.LastError = "webhook was deleted"
+			case !subscription.Active:
+				delivery.Status = models.WebhookDeliveryCancelled
+				delivery.LastError = "webhook is paused"
+			default:
+				delivery.Attempts++
+				delivery.NextAttemptAt = now.Add(d.policy.Lease)
+				claimed = append(claimed, claimedDelivery{delivery: *delivery, subscription: *subscription})
+			}
+			if err := tx.Deliveries.Update(delivery); err != nil {
+				return fmt.Errorf("failed to claim webhook delivery: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		d.logger.Log("ERROR", "Failed to claim webhook deliveries: "+err.Error())
+		return 0, err
+	}
+
+	for _, c := range claimed {
+		if ctx.Err() != nil {
+			break
+		}
+		d.send(ctx, c.delivery, c.subscription)
+	}
+	return count, nil
+}
+
+// send makes one attempt at a claimed delivery and records the outcome. A
+// delivery sent but not marked so is sent again after its lease, which
+// receivers deduplicate by X-Hex-Delivery.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery models.WebhookDelivery, subscription models.WebhookSubscription) {
+	status, err := d.sender.Send(ctx, events.WebhookRequest{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		DeliveryID: delivery.ID,
+		EventType:  delivery.EventType,
+		Body:       delivery.Payload,
+	})
+	now := d.now()
+	delivery.LastStatusCode = status
+	fields := []logging.Field{
+		logging.F("delivery_id", delivery.ID), logging.F("webhook_id", subscription.ID),
+		logging.F("event_id", delivery.EventID), logging.F("attempts", delivery.Attempts),
+	}
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= d.policy.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = truncateError(err)
+		d.logger.Log("ERROR", "Webhook delivery dead-lettered: "+err.Error(), fields...)
+	default:
+		retryAt := now.Add(d.policy.RetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = retryAt
+		delivery.LastError = truncateError(err)
+		d.logger.Log("WARN", "Failed to deliver webhook: "+err.Error(), append(fields, logging.F("retry_at", retryAt))...)
+	}
+
+	if err := d.deliveryRepo.Update(&delivery); err != nil {
+		d.logger.Log("ERROR", "Failed to record webhook delivery: "+err.Error(), logging.F("delivery_id", delivery.ID))
+		return
+	}
+	if delivery.Status == models.WebhookDeliveryDelivered {
+		d.logger.Log("INFO", "Webhook delivered", fields...)
+	}
+}
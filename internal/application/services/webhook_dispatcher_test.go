@@ -0,0 +1,212 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	eventadapters "hex/internal/adapters/events"
+	"hex/internal/application/repositories"
+	"hex/internal/application/services"
+	"hex/pkg/models"
+)
+
+const testWebhookSecret = "0123456789abcdef0123"
+
+// receiver is an httptest webhook endpoint answering with status and
+// keeping every request it gets.
+type receiver struct {
+	*httptest.Server
+	mu      sync.Mutex
+	status  int
+	bodies  [][]byte
+	headers []http.Header
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	rcv := &receiver{status: status}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.bodies = append(rcv.bodies, body)
+		rcv.headers = append(rcv.headers, r.Header.Clone())
+		w.WriteHeader(rcv.status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+// webhookFixture subscribes a receiver to BookCreated and creates a book.
+type webhookFixture struct {
+	ts         *testStore
+	service    services.WebhookService
+	dispatcher *services.WebhookDispatcher
+	webhook    models.WebhookSubscription
+	clock      *clock
+}
+
+func newWebhookFixture(t *testing.T, url string, policy services.WebhookPolicy) *webhookFixture {
+	t.Helper()
+	f := &webhookFixture{ts: newTestStore(), clock: newClock()}
+
+	f.service = services.NewWebhookService(f.ts.webhooks, f.ts.deliveries, f.ts.transactor, nopLogger{})
+	services.SetClock(f.service, f.clock.now)
+	f.dispatcher = services.NewWebhookDispatcher(f.ts.deliveries, f.ts.transactor, eventadapters.NewHTTPWebhookSender(time.Second), policy, nopLogger{})
+	services.SetClock(f.dispatcher, f.clock.now)
+
+	f.webhook = models.WebhookSubscription{URL: url, Secret: testWebhookSecret, Active: true}
+	if err := f.service.CreateWebhook(&f.webhook, []string{models.EventBookCreated}, services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	books := services.NewBookService(f.ts.books, f.ts.transactor, nopLogger{})
+	services.SetClock(books, f.clock.now)
+	if err := books.CreateBook(newTestBook("Always Coming Home", 0), services.Actor{UserID: 1}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	return f
+}
+
+// delivery returns the only delivery queued for the fixture's webhook.
+func (f *webhookFixture) delivery(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+	deliveries, _, err := f.service.ListDeliveries(f.webhook.ID, repositories.WebhookDeliveryQuery{})
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func testWebhookPolicy() services.WebhookPolicy {
+	return services.WebhookPolicy{BatchSize: 10, Lease: time.Minute, RetryBase: time.Minute, RetryMax: time.Hour, MaxAttempts: 3}
+}
+
+func TestWebhookDispatcherSignsBody(t *testing.T) {
+	rcv := newReceiver(t, http.StatusNoContent)
+	f := newWebhookFixture(t, rcv.URL, testWebhookPolicy())
+
+	if _, err := f.dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+
+	if len(rcv.bodies) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rcv.bodies))
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(rcv.bodies[0])
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := rcv.headers[0].Get("X-Hex-Signature"); got != want {
+		t.Errorf("X-Hex-Signature = %q, want %q", got, want)
+	}
+	if got := rcv.headers[0].Get("X-Hex-Event"); got != models.EventBookCreated {
+		t.Errorf("X-Hex-Event = %q, want %q", got, models.EventBookCreated)
+	}
+
+	delivery := f.delivery(t)
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.DeliveredAt == nil {
+		t.Errorf("delivery status = %q, delivered at %v; want delivered", delivery.Status, delivery.DeliveredAt)
+	}
+	if string(rcv.bodies[0]) != string(delivery.Payload) {
+		t.Errorf("body sent = %s, want the stored payload %s", rcv.bodies[0], delivery.Payload)
+	}
+}
+
+func TestWebhookDispatcherBacksOffThenDeadLetters(t *testing.T) {
+	rcv := newReceiver(t, http.StatusServiceUnavailable)
+	f := newWebhookFixture(t, rcv.URL, testWebhookPolicy())
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if _, err := f.dispatcher.DispatchDue(context.Background()); err != nil {
+			t.Fatalf("DispatchDue: %v", err)
+		}
+		delivery := f.delivery(t)
+		if delivery.Status != models.WebhookDeliveryPending {
+			t.Fatalf("after attempt %d status = %q, want pending", attempt+1, delivery.Status)
+		}
+		if delivery.Attempts != uint(attempt+1) || delivery.LastStatusCode != http.StatusServiceUnavailable {
+			t.Errorf("after attempt %d attempts = %d, last status = %d", attempt+1, delivery.Attempts, delivery.LastStatusCode)
+		}
+		if want := f.clock.Add(wait); !delivery.NextAttemptAt.Equal(want) {
+			t.Errorf("after attempt %d next attempt at %v, want %v", attempt+1, delivery.NextAttemptAt, want)
+		}
+
+		// Nothing is sent again before the retry is due
+		sent := len(rcv.bodies)
+		f.dispatcher.DispatchDue(context.Background())
+		if len(rcv.bodies) != sent {
+			t.Fatalf("delivery was retried before it was due")
+		}
+		f.clock.Time = delivery.NextAttemptAt
+	}
+
+	if _, err := f.dispatcher.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	delivery := f.delivery(t)
+	if delivery.Status != models.WebhookDeliveryDead || delivery.Attempts != 3 {
+		t.Fatalf("after MaxAttempts status = %q, attempts = %d; want dead after 3", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastError == "" {
+		t.Error("dead delivery has no LastError")
+	}
+
+	f.clock.advance(24 * time.Hour)
+	f.dispatcher.DispatchDue(context.Background())
+	if len(rcv.bodies) != 3 {
+		t.Errorf("receiver got %d requests, want a dead delivery to stop at 3", len(rcv.bodies))
+	}
+}
+
+func TestRetryDeliveryRequeuesDeadDelivery(t *testing.T) {
+	rcv := newReceiver(t, http.StatusInternalServerError)
+	policy := testWebhookPolicy()
+	policy.MaxAttempts = 1
+	f := newWebhookFixture(t, rcv.URL, policy)
+
+	f.dispatcher.DispatchDue(context.Background())
+	dead := f.delivery(t)
+	if dead.Status != models.WebhookDeliveryDead {
+		t.Fatalf("status = %q, want dead", dead.Status)
+	}
+
+	admin := services.Actor{UserID: 1, Role: "admin", RequestID: "req-1"}
+	retried, err := f.service.RetryDelivery(f.webhook.ID, dead.ID, admin)
+	if err != nil {
+		t.Fatalf("RetryDelivery: %v", err)
+	}
+	if retried.Status != models.WebhookDeliveryPending || retried.Attempts != 0 || retried.LastError != "" {
+		t.Errorf("retried delivery = %q, %d attempts, error %q; want pending and reset", retried.Status, retried.Attempts, retried.LastError)
+	}
+	if !retried.NextAttemptAt.Equal(f.clock.Time) {
+		t.Errorf("next attempt at %v, want now", retried.NextAttemptAt)
+	}
+	if _, err := f.service.RetryDelivery(f.webhook.ID, dead.ID, admin); err != services.ErrDeliveryPending {
+		t.Errorf("retrying a pending delivery: err = %v, want ErrDeliveryPending", err)
+	}
+
+	entries, _, err := f.ts.audit.List(repositories.AuditQuery{Filter: repositories.AuditFilter{EntityType: models.AuditEntityWebhookDelivery}})
+	if err != nil {
+		t.Fatalf("List audit: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditActionRetry || entries[0].EntityID != dead.ID || entries[0].RequestID != "req-1" {
+		t.Errorf("audit entries = %+v, want one retry of delivery %d", entries, dead.ID)
+	}
+
+	rcv.mu.Lock()
+	rcv.status = http.StatusOK
+	rcv.mu.Unlock()
+	f.dispatcher.DispatchDue(context.Background())
+	if delivery := f.delivery(t); delivery.Status != models.WebhookDeliveryDelivered {
+		t.Errorf("after retry status = %q, want delivered", delivery.Status)
+	}
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"hex/internal/application/logging"
+	"hex/internal/application/repositories"
+	"hex/pkg/models"
+)
+
+// Bounds on the secret a webhook is signed with.
+const (
+	minWebhookSecret = 16
+	maxWebhookSecret = 128
+)
+
+// WebhookChange lists the fields of a webhook to change; nil fields are
+// kept.
+type WebhookChange struct {
+	URL         *string
+	Description *string
+	EventTypes  *[]string
+	Secret      *string
+	Active      *bool
+}
+
+type WebhookService interface {
+	// CreateWebhook subscribes a URL to the given event types. An empty
+	// secret is generated; either way it is left in subscription.Secret for
+	// the caller to show once.
+	CreateWebhook(subscription *models.WebhookSubscription, eventTypes []string, actor Actor) error
+	ListWebhooks() ([]models.WebhookSubscription, error)
+	GetWebhook(id uint) (*models.WebhookSubscription, error)
+	// UpdateWebhook changes a webhook. Deliveries already queued for a
+	// webhook that is paused are cancelled rather than sent.
+	UpdateWebhook(id uint, change WebhookChange, actor Actor) (*models.WebhookSubscription, error)
+	DeleteWebhook(id uint, actor Actor) error
+	// ListDeliveries returns one page of a webhook's delivery log, newest
+	// first, and the total number of matching deliveries.
+	ListDeliveries(webhookID uint, query repositories.WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error)
+	// RetryDelivery queues a dead or cancelled delivery to be sent again,
+	// with a fresh set of attempts.
+	RetryDelivery(webhookID, deliveryID uint, actor Actor) (*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhookRepo  repositories.WebhookRepository
+	deliveryRepo repositories.WebhookDeliveryRepository
+	transactor   repositories.Transactor
+	logger       logging.Logger
+	now          func() time.Time
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, deliveryRepo repositories.WebhookDeliveryRepository, transactor repositories.Transactor, logger logging.Logger) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		transactor:   transactor,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+func (s *webhookService) CreateWebhook(subscription *models.WebhookSubscription, eventTypes []string, actor Actor) error {
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		if subscription.Secret == "" {
+			secret, err := newWebhookSecret()
+			if err != nil {
+				return err
+			}
+			subscription.Secret = secret
+		}
+		encoded, err := encodeEventTypes(eventTypes)
+		if err != nil {
+			return err
+		}
+		subscription.EventTypes = encoded
+		subscription.URL = strings.TrimSpace(subscription.URL)
+		if err := validateWebhook(subscription); err != nil {
+			return err
+		}
+
+		subscription.ID = 0
+		if err := tx.Webhooks.Create(subscription); err != nil {
+			return fmt.Errorf("failed to create webhook: %w", err)
+		}
+		return recordAudit(tx, actor, models.AuditActionCreate, models.AuditEntityWebhook, subscription.ID, nil, subscription)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("url", subscription.URL))
+		return err
+	}
+
+	s.logger.Log("INFO", "Webhook created", logging.F("webhook_id", subscription.ID), logging.F("url", subscription.URL))
+	return nil
+}
+
+func (s *webhookService) ListWebhooks() ([]models.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.List(false)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get webhooks: "+err.Error())
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Retrieved webhooks", logging.F("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+func (s *webhookService) GetWebhook(id uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get webhook by ID: "+err.Error(), logging.F("webhook_id", id))
+		return nil, err
+	}
+	if subscription == nil {
+		s.logger.Log("ERROR", ErrWebhookNotFound.Error(), logging.F("webhook_id", id))
+		return nil, ErrWebhookNotFound
+	}
+
+	s.logger.Log("INFO", "Retrieved webhook", logging.F("webhook_id", id))
+	return subscription, nil
+}
+
+func (s *webhookService) UpdateWebhook(id uint, change WebhookChange, actor Actor) (*models.WebhookSubscription, error) {
+	var updated *models.WebhookSubscription
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		subscription, err := tx.Webhooks.GetByIDForUpdate(id)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook by ID: %w", err)
+		}
+		if subscription == nil {
+			return ErrWebhookNotFound
+		}
+		before, err := snapshot(subscription)
+		if err != nil {
+			return err
+		}
+
+		if change.URL != nil {
+			subscription.URL = strings.TrimSpace(*change.URL)
+		}
+		if change.Description != nil {
+			subscription.Description = *change.Description
+		}
+		if change.EventTypes != nil {
+			if subscription.EventTypes, err = encodeEventTypes(*change.EventTypes); err != nil {
+				return err
+			}
+		}
+		if change.Secret != nil {
+			subscription.Secret = *change.Secret
+		}
+		if change.Active != nil {
+			subscription.Active = *change.Active
+		}
+		if err := validateWebhook(subscription); err != nil {
+			return err
+		}
+
+		if err := tx.Webhooks.Update(subscription); err != nil {
+			return fmt.Errorf("failed to update webhook: %w", err)
+		}
+		updated = subscription
+		return recordAudit(tx, actor, models.AuditActionUpdate, models.AuditEntityWebhook, subscription.ID, before, subscription)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("webhook_id", id))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Webhook updated", logging.F("webhook_id", id), logging.F("active", updated.Active))
+	return updated, nil
+}
+
+func (s *webhookService) DeleteWebhook(id uint, actor Actor) error {
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		subscription, err := tx.Webhooks.GetByIDForUpdate(id)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook by ID: %w", err)
+		}
+		if subscription == nil {
+			return ErrWebhookNotFound
+		}
+
+		if err := tx.Webhooks.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		return recordAudit(tx, actor, models.AuditActionDelete, models.AuditEntityWebhook, id, subscription, nil)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("webhook_id", id))
+		return err
+	}
+
+	s.logger.Log("INFO", "Webhook deleted", logging.F("webhook_id", id))
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(webhookID uint, query repositories.WebhookDeliveryQuery) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, 0, err
+	}
+
+	query.Filter.SubscriptionID = webhookID
+	deliveries, total, err := s.deliveryRepo.List(query)
+	if err != nil {
+		s.logger.Log("ERROR", "Failed to get webhook deliveries: "+err.Error(), logging.F("webhook_id", webhookID))
+		return nil, 0, err
+	}
+
+	s.logger.Log("INFO", "Retrieved webhook deliveries", logging.F("webhook_id", webhookID), logging.F("count", len(deliveries)), logging.F("total", total))
+	return deliveries, total, nil
+}
+
+func (s *webhookService) RetryDelivery(webhookID, deliveryID uint, actor Actor) (*models.WebhookDelivery, error) {
+	var retried *models.WebhookDelivery
+	err := s.transactor.WithinTransaction(func(tx repositories.Tx) error {
+		subscription, err := tx.Webhooks.GetByID(webhookID)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook by ID: %w", err)
+		}
+		if subscription == nil {
+			return ErrWebhookNotFound
+		}
+		delivery, err := tx.Deliveries.GetByIDForUpdate(deliveryID)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook delivery by ID: %w", err)
+		}
+		if delivery == nil || delivery.SubscriptionID != webhookID {
+			return ErrDeliveryNotFound
+		}
+		if delivery.Status == models.WebhookDeliveryPending {
+			return ErrDeliveryPending
+		}
+		before, err := snapshot(delivery)
+		if err != nil {
+			return err
+		}
+
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = s.now()
+		delivery.LastError = ""
+		delivery.DeliveredAt = nil
+		if err := tx.Deliveries.Update(delivery); err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+		retried = delivery
+		return recordAudit(tx, actor, models.AuditActionRetry, models.AuditEntityWebhookDelivery, delivery.ID, before, delivery)
+	})
+	if err != nil {
+		s.logger.Log("ERROR", err.Error(), logging.F("webhook_id", webhookID), logging.F("delivery_id", deliveryID))
+		return nil, err
+	}
+
+	s.logger.Log("INFO", "Webhook delivery queued again", logging.F("webhook_id", webhookID), logging.F("delivery_id", deliveryID))
+	return retried, nil
+}
+
+// newWebhookSecret generates a random secret to sign deliveries with.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// encodeEventTypes checks that eventTypes lists known event types and
+// encodes them without duplicates for WebhookSubscription.EventTypes.
+func encodeEventTypes(eventTypes []string) ([]byte, error) {
+	if len(eventTypes) == 0 {
+		return nil, &ValidationError{Field: "event_types", Message: "at least one event type is required"}
+	}
+	var unique []string
+	for _, eventType := range eventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return nil, &ValidationError{Field: "event_types", Message: "event types must be among " + strings.Join(models.EventTypes, ", ")}
+		}
+		if !slices.Contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+	encoded, err := json.Marshal(unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event types: %w", err)
+	}
+	return encoded, nil
+}
+
+func validateWebhook(subscription *models.WebhookSubscription) error {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return &ValidationError{Field: "url", Message: "url must be an absolute http or https URL"}
+	}
+	if len(subscription.URL) > 2048 {
+		return &ValidationError{Field: "url", Message: "url must be at most 2048 characters"}
+	}
+	if len(subscription.Description) > 255 {
+		return &ValidationError{Field: "description", Message: "description must be at most 255 characters"}
+	}
+	if len(subscription.Secret) < minWebhookSecret || len(subscription.Secret) > maxWebhookSecret {
+		return &ValidationError{Field: "secret", Message: fmt.Sprintf("secret must be between %d and %d characters", minWebhookSecret, maxWebhookSecret)}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Audited entity types.
+const (
+	AuditEntityBook            = "book"
+	AuditEntityCopy            = "copy"
+	AuditEntityBorrowingRecord = "borrowing_record"
+	AuditEntityFine            = "fine"
+	AuditEntityHold            = "hold"
+	AuditEntityWebhook         = "webhook"
+	AuditEntityWebhookDelivery = "webhook_delivery"
+)
+
+// AuditEntities lists every audited entity type.
+var AuditEntities = []string{AuditEntityBook, AuditEntityCopy, AuditEntityBorrowingRecord, AuditEntityFine, AuditEntityHold, AuditEntityWebhook, AuditEntityWebhookDelivery}
+
+// Audited actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionBorrow  = "borrow"
+	AuditActionReturn  = "return"
+	AuditActionRenew   = "renew"
+	AuditActionCancel  = "cancel"
+	AuditActionPay     = "pay"
+	AuditActionWaive   = "waive"
+	AuditActionRetry   = "retry"
+)
+
+// AuditEntry records one change: who made it, in which request, and what it
+// changed. Entries are written in the same transaction as the change and
+// never updated or deleted afterwards, so they outlive even purged books.
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index;not null"`
+	// ActorID is zero, and ActorRole empty, for changes the system made on
+	// its own.
+	ActorID    uint   `gorm:"index;not null"`
+	ActorRole  string `gorm:"size:16;not null"`
+	Action     string `gorm:"size:16;not null"`
+	EntityType string `gorm:"size:32;not null;index:idx_audit_entries_entity"`
+	EntityID   uint   `gorm:"not null;index:idx_audit_entries_entity"`
+	// Changes maps each field that changed to an object holding its value
+	// "before" and "after". Fields of new entities have no before; fields of
+	// removed ones have no after.
+	Changes   datatypes.JSON
+	RequestID string `gorm:"size:64;index"`
+}
@@ -9,11 +9,30 @@ import (
 
 type Book struct {
 	gorm.Model
-	Title           string         `gorm:"not null"`
+	Title string `gorm:"not null"`
+	// Author is the byline shown in listings: the names in Authors joined by
+	// commas.
 	Author          string         `gorm:"not null"`
 	PublicationDate datatypes.Date `gorm:"type:date;not null"`
 	Genre           string
-	Availability    uint `gorm:"default:1"`
+	// ISBN is stored as 13 digits; see NormalizeISBN.
+	ISBN        *string `gorm:"size:13;uniqueIndex"`
+	Publisher   string
+	Edition     string
+	Language    string `gorm:"size:35"`
+	PageCount   uint   `gorm:"not null;default:0"`
+	Description string `gorm:"type:text"`
+	CoverURL    string
+	Authors     []Author  `gorm:"many2many:book_authors"`
+	Subjects    []Subject `gorm:"many2many:book_subjects"`
+	// Availability is the number of copies on the shelf. It is counted from
+	// Copies when the book is read and never stored.
+	Availability uint `gorm:"->;-:migration"`
+	// LoanPeriodDays overrides the configured loan period when non-zero.
+	LoanPeriodDays uint
+	// Version counts the updates to the book, starting at 1. Clients send it
+	// back to make sure they are not overwriting a change they never saw.
+	Version uint `gorm:"not null;default:1"`
 }
 
 func (b *Book) BeforeCreate(tx *gorm.DB) error {
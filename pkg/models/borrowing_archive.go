@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// BorrowingArchive preserves a borrowing record, and the fine charged on it,
+// after its book has been purged. The book and copy are gone by then, so the
+// details worth keeping are copied in.
+type BorrowingArchive struct {
+	ID                uint    `gorm:"primaryKey"`
+	BorrowingRecordID uint    `gorm:"uniqueIndex;not null"`
+	BookID            uint    `gorm:"index;not null"`
+	BookTitle         string  `gorm:"not null"`
+	BookAuthor        string  `gorm:"not null"`
+	BookISBN          *string `gorm:"size:13"`
+	CopyBarcode       string  `gorm:"size:64"`
+	MemberID          uint    `gorm:"index;not null"`
+	BorrowDate        time.Time
+	DueDate           time.Time `gorm:"default:null"`
+	ReturnDate        *time.Time
+	RenewalCount      uint
+	// The fine fields total every fine charged on the record and are zero
+	// when none was. FineStatus is paid when all of them were paid in full
+	// and waived when any part was forgiven.
+	FineAmountCents int64
+	FinePaidCents   int64
+	FineStatus      string `gorm:"size:16"`
+	// ArchivedBy is the admin who purged the book.
+	ArchivedBy uint      `gorm:"not null"`
+	ArchivedAt time.Time `gorm:"not null"`
+}
+
+// AddFine adds a settled fine to the archived fine totals.
+func (a *BorrowingArchive) AddFine(fine Fine) {
+	a.FineAmountCents += fine.AmountCents
+	a.FinePaidCents += fine.PaidCents
+	if a.FineStatus != FineStatusWaived {
+		a.FineStatus = fine.Status
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestBorrowingArchiveAddFine(t *testing.T) {
+	tests := []struct {
+		name   string
+		fines  []Fine
+		amount int64
+		paid   int64
+		status string
+	}{
+		{"none", nil, 0, 0, ""},
+		{"paid", []Fine{{AmountCents: 100, PaidCents: 100, Status: FineStatusPaid}, {AmountCents: 50, PaidCents: 50, Status: FineStatusPaid}}, 150, 150, FineStatusPaid},
+		{"waived first", []Fine{{AmountCents: 100, PaidCents: 40, Status: FineStatusWaived}, {AmountCents: 50, PaidCents: 50, Status: FineStatusPaid}}, 150, 90, FineStatusWaived},
+		{"waived last", []Fine{{AmountCents: 50, PaidCents: 50, Status: FineStatusPaid}, {AmountCents: 100, Status: FineStatusWaived}}, 150, 50, FineStatusWaived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive BorrowingArchive
+			for _, fine := range tt.fines {
+				archive.AddFine(fine)
+			}
+			if archive.FineAmountCents != tt.amount || archive.FinePaidCents != tt.paid || archive.FineStatus != tt.status {
+				t.Errorf("archive fine = %d/%d %q, want %d/%d %q", archive.FinePaidCents, archive.FineAmountCents, archive.FineStatus, tt.paid, tt.amount, tt.status)
+			}
+		})
+	}
+}
@@ -3,10 +3,32 @@ package models
 import "time"
 
 type BorrowingRecord struct {
-	ID         uint `gorm:"primaryKey"`
-	BookID     uint
-	Book       Book `gorm:"foreignKey:BookID"`
-	MemberID   uint
-	BorrowDate time.Time
-	ReturnDate time.Time `gorm:"default:null"`
+	ID     uint `gorm:"primaryKey"`
+	BookID uint
+	Book   Book `gorm:"foreignKey:BookID"`
+	// CopyID is the copy lent out. Records from before copies were tracked
+	// may have none.
+	CopyID       *uint `gorm:"index"`
+	Copy         *Copy `gorm:"foreignKey:CopyID"`
+	MemberID     uint
+	BorrowDate   time.Time
+	DueDate      time.Time `gorm:"default:null;index"`
+	ReturnDate   *time.Time
+	RenewalCount uint `gorm:"not null;default:0"`
+	// OverdueNotifiedAt is when the LoanOverdue event went out for the
+	// current due date. Renewing the loan clears it.
+	OverdueNotifiedAt *time.Time
+	// Overdue is computed when the record is read and never stored.
+	Overdue bool `gorm:"-"`
+}
+
+// IsReturned reports whether the book has been brought back.
+func (r *BorrowingRecord) IsReturned() bool {
+	return r.ReturnDate != nil
+}
+
+// IsOverdue reports whether the book is still out after its due date.
+// Records created before due dates existed have none and are never overdue.
+func (r *BorrowingRecord) IsOverdue(now time.Time) bool {
+	return !r.IsReturned() && !r.DueDate.IsZero() && r.DueDate.Before(now)
 }
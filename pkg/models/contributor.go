@@ -0,0 +1,14 @@
+package models
+
+// Author is a person credited on books. Names are unique, so the same
+// author is shared by every book they wrote.
+type Author struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:255;not null;uniqueIndex"`
+}
+
+// Subject is a topic or tag books are catalogued under. Names are unique.
+type Subject struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:100;not null;uniqueIndex"`
+}
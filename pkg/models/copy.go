@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Copy statuses. Only available copies can be borrowed.
+const (
+	CopyStatusAvailable   = "available"
+	CopyStatusOnLoan      = "on_loan"
+	CopyStatusMaintenance = "maintenance"
+	CopyStatusLost        = "lost"
+	CopyStatusWithdrawn   = "withdrawn"
+)
+
+// CopyStatuses lists every copy status.
+var CopyStatuses = []string{CopyStatusAvailable, CopyStatusOnLoan, CopyStatusMaintenance, CopyStatusLost, CopyStatusWithdrawn}
+
+// Copy conditions, from best to worst.
+const (
+	CopyConditionNew     = "new"
+	CopyConditionGood    = "good"
+	CopyConditionFair    = "fair"
+	CopyConditionPoor    = "poor"
+	CopyConditionDamaged = "damaged"
+)
+
+// CopyConditions lists every copy condition.
+var CopyConditions = []string{CopyConditionNew, CopyConditionGood, CopyConditionFair, CopyConditionPoor, CopyConditionDamaged}
+
+// Copy is one physical item of a Book. A book's availability is the number
+// of its copies with status available.
+type Copy struct {
+	gorm.Model
+	BookID    uint   `gorm:"index;not null"`
+	Barcode   string `gorm:"size:64;not null;uniqueIndex"`
+	Status    string `gorm:"size:16;not null;default:available;index"`
+	Condition string `gorm:"size:16;not null;default:good"`
+	// Location is the shelf or branch the copy belongs on.
+	Location string
+}
+
+// CopyBarcode returns the barcode given to the seq-th copy of a book when
+// none is supplied, such as "0000042-003".
+func CopyBarcode(bookID uint, seq int) string {
+	return fmt.Sprintf("%07d-%03d", bookID, seq)
+}
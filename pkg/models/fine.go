@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fine statuses.
+const (
+	FineStatusUnpaid = "unpaid"
+	FineStatusPaid   = "paid"
+	FineStatusWaived = "waived"
+)
+
+// FineStatuses lists every fine status.
+var FineStatuses = []string{FineStatusUnpaid, FineStatusPaid, FineStatusWaived}
+
+// Fine is charged when a book comes back after its due date. Amounts are in
+// cents. A fine is settled once PaidCents reaches AmountCents or a librarian
+// waives it.
+type Fine struct {
+	gorm.Model
+	BorrowingRecordID uint            `gorm:"uniqueIndex;not null"`
+	BorrowingRecord   BorrowingRecord `gorm:"foreignKey:BorrowingRecordID"`
+	MemberID          uint            `gorm:"index;not null"`
+	DaysLate          uint            `gorm:"not null"`
+	AmountCents       int64           `gorm:"not null"`
+	PaidCents         int64           `gorm:"not null;default:0"`
+	Status            string          `gorm:"size:16;not null;default:unpaid;index"`
+	// ResolvedBy is the librarian or admin who settled or waived the fine.
+	ResolvedBy uint
+	ResolvedAt *time.Time
+	Note       string
+}
+
+// OutstandingCents is what the member still owes on this fine.
+func (f *Fine) OutstandingCents() int64 {
+	if f.Status != FineStatusUnpaid {
+		return 0
+	}
+	return f.AmountCents - f.PaidCents
+}
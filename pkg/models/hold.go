@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Hold statuses. Waiting and ready holds are active; the rest are final.
+const (
+	HoldStatusWaiting   = "waiting"
+	HoldStatusReady     = "ready"
+	HoldStatusFulfilled = "fulfilled"
+	HoldStatusCancelled = "cancelled"
+	HoldStatusExpired   = "expired"
+)
+
+// Hold is a member's place in the queue for a book with no copies left.
+// Holds are served first come, first served by ID. When a copy comes back it
+// is set aside for the first waiting hold, which becomes ready until
+// ExpiresAt.
+type Hold struct {
+	gorm.Model
+	BookID    uint   `gorm:"index;not null"`
+	Book      Book   `gorm:"foreignKey:BookID"`
+	MemberID  uint   `gorm:"index;not null"`
+	Status    string `gorm:"size:16;not null;default:waiting;index"`
+	ReadyAt   *time.Time
+	ExpiresAt *time.Time
+	// Position is 1 for the first waiting hold in the queue and 0 once the
+	// hold is ready. It is computed when the hold is read and never stored.
+	Position int `gorm:"-"`
+}
+
+// IsActive reports whether the hold is still waiting or ready.
+func (h *Hold) IsActive() bool {
+	return h.Status == HoldStatusWaiting || h.Status == HoldStatusReady
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeISBN checks an ISBN-10 or ISBN-13, which may contain hyphens or
+// spaces, and returns it as 13 digits so both forms of the same book compare
+// equal.
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(isbn)))
+
+	switch len(digits) {
+	case 10:
+		if !validISBN10(digits) {
+			return "", fmt.Errorf("invalid ISBN-10 %q", isbn)
+		}
+		isbn13 := "978" + digits[:9]
+		return isbn13 + isbn13CheckDigit(isbn13), nil
+	case 13:
+		if !validISBN13(digits) {
+			return "", fmt.Errorf("invalid ISBN-13 %q", isbn)
+		}
+		return digits, nil
+	default:
+		return "", fmt.Errorf("ISBN %q must have 10 or 13 digits", isbn)
+	}
+}
+
+// validISBN10 checks the mod 11 checksum; the last character may be X for 10.
+func validISBN10(digits string) bool {
+	sum := 0
+	for i, r := range digits {
+		var value int
+		switch {
+		case r >= '0' && r <= '9':
+			value = int(r - '0')
+		case r == 'X' && i == 9:
+			value = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * value
+	}
+	return sum%11 == 0
+}
+
+// validISBN13 checks the EAN-13 checksum and the 978 or 979 prefix that
+// marks an EAN as an ISBN.
+func validISBN13(digits string) bool {
+	if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return isbn13CheckDigit(digits[:12]) == digits[12:]
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an
+// ISBN-13.
+func isbn13CheckDigit(digits string) string {
+	sum := 0
+	for i, r := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+	return fmt.Sprint((10 - sum%10) % 10)
+}
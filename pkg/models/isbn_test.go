@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want string
+	}{
+		{"978-0-547-77374-2", "9780547773742"},
+		{"979 10 90636 07 1", "9791090636071"},
+		{"0-547-77374-9", "9780547773742"},
+		{"0-8044-2957-x", "9780804429573"},
+	}
+	for _, tt := range tests {
+		if got, err := NormalizeISBN(tt.isbn); err != nil || got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, %v; want %q", tt.isbn, got, err, tt.want)
+		}
+	}
+
+	for _, isbn := range []string{
+		"978-0-547-77374-3", // wrong check digit
+		"4006381333931",     // a valid EAN-13 that is not an ISBN
+		"0-547-77374-5",     // wrong ISBN-10 check digit
+		"97805477737",       // too short
+		"978054777374X",
+	} {
+		if got, err := NormalizeISBN(isbn); err == nil {
+			t.Errorf("NormalizeISBN(%q) = %q, want an error", isbn, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Domain event types.
+const (
+	EventBookCreated   = "BookCreated"
+	EventBookAvailable = "BookAvailable"
+	EventBookBorrowed  = "BookBorrowed"
+	EventBookReturned  = "BookReturned"
+	EventLoanOverdue   = "LoanOverdue"
+)
+
+// EventTypes lists every domain event type.
+var EventTypes = []string{EventBookCreated, EventBookAvailable, EventBookBorrowed, EventBookReturned, EventLoanOverdue}
+
+// OutboxEvent is a domain event saved in the same transaction as the change
+// it describes and relayed to other systems afterwards. It stays in the
+// outbox, unpublished, until a publisher accepts it, so events are delivered
+// at least once even across crashes.
+type OutboxEvent struct {
+	ID   uint   `gorm:"primaryKey"`
+	Type string `gorm:"size:64;not null"`
+	// EntityID is the book or borrowing record the event is about.
+	EntityID   uint           `gorm:"not null"`
+	Payload    datatypes.JSON `gorm:"not null"`
+	OccurredAt time.Time      `gorm:"not null"`
+	// Attempts counts the times a dispatcher has tried to publish the event;
+	// NextAttemptAt is when it may try again.
+	Attempts      uint       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_pending,priority:2"`
+	PublishedAt   *time.Time `gorm:"index:idx_outbox_events_pending,priority:1"`
+	LastError     string     `gorm:"size:1024"`
+}
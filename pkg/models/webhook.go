@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses. Pending deliveries are still being tried; the
+// rest are final. Dead deliveries failed every attempt; cancelled ones were
+// dropped because their subscription was deleted or paused.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+	WebhookDeliveryCancelled = "cancelled"
+)
+
+// WebhookDeliveryStatuses lists every webhook delivery status.
+var WebhookDeliveryStatuses = []string{WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryDead, WebhookDeliveryCancelled}
+
+// WebhookSubscription asks for the domain events of the listed types to be
+// POSTed to URL. Each delivery is signed with Secret, which is never shown
+// after the subscription is created.
+type WebhookSubscription struct {
+	gorm.Model
+	URL         string `gorm:"size:2048;not null"`
+	Description string `gorm:"size:255"`
+	// EventTypes is a JSON array of the event types subscribed to.
+	EventTypes datatypes.JSON `gorm:"not null"`
+	Secret     string         `gorm:"size:128;not null" json:"-"`
+	Active     bool           `gorm:"not null"`
+}
+
+// Events decodes EventTypes.
+func (s *WebhookSubscription) Events() []string {
+	var types []string
+	json.Unmarshal(s.EventTypes, &types)
+	return types
+}
+
+// Subscribes reports whether the subscription wants events of eventType.
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return slices.Contains(s.Events(), eventType)
+}
+
+// WebhookDelivery is one event on its way to one subscription, and the log
+// of how that went. Payload is the exact body sent on every attempt.
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primaryKey"`
+	SubscriptionID uint           `gorm:"not null;index"`
+	EventID        uint           `gorm:"not null"`
+	EventType      string         `gorm:"size:64;not null"`
+	Payload        datatypes.JSON `gorm:"not null"`
+	Status         string         `gorm:"size:16;not null;default:pending;index:idx_webhook_deliveries_pending,priority:1"`
+	// Attempts counts the times the delivery has been tried; NextAttemptAt
+	// is when it may be tried again.
+	Attempts      uint      `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_deliveries_pending,priority:2"`
+	// LastStatusCode is what the subscriber answered the last attempt with,
+	// or 0 when it did not answer.
+	LastStatusCode int
+	LastError      string `gorm:"size:1024"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}